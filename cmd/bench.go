@@ -0,0 +1,357 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/matheusc457/ps2smb/internal/bench"
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/directio"
+	"github.com/matheusc457/ps2smb/internal/network"
+	"github.com/matheusc457/ps2smb/internal/smb1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchLocal    bool
+	benchHost     string
+	benchDuration time.Duration
+	benchPatterns []string
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench [game]",
+	Short: "Benchmark reading a game the way OPL does",
+	Long: `Opens a game ISO over the share with the built-in SMB1 client and replays
+OPL-like read patterns, reporting throughput and latency percentiles.
+
+Patterns:
+  sequential  16-sector reads in order, like loading a level
+  small       single-sector reads in order, like file system lookups
+  stream      seek, then 8 x 16-sector reads, like FMV and audio streaming
+  seek        single-sector reads at random sectors
+
+The game is matched by file name (or part of it) in DVD/ and CD/; without
+an argument the largest image is used. With --local the image is read
+straight from disk with direct I/O, bypassing the page cache, so the numbers
+show what the disk itself delivers. Run on the server, the SMB benchmark
+goes over loopback: it measures Samba and the disk, not the PS2's link.
+For user authentication the password is read from PS2SMB_PASSWORD.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		query := ""
+		if len(args) > 0 {
+			query = args[0]
+		}
+		if err := runBench(query); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+	benchCmd.Flags().BoolVar(&benchLocal, "local", false, "Read the image from local disk instead of over SMB")
+	benchCmd.Flags().StringVar(&benchHost, "host", "", "Server address to connect to (default: the address given to the PS2)")
+	benchCmd.Flags().DurationVarP(&benchDuration, "duration", "d", 5*time.Second, "How long to run each pattern")
+	benchCmd.Flags().StringSliceVarP(&benchPatterns, "pattern", "p", nil, "Patterns to run (default: all)")
+	addOutputFlags(benchCmd)
+}
+
+// benchReport is the structured output of 'ps2smb bench'
+type benchReport struct {
+	Game    string          `json:"game"`
+	Source  string          `json:"source"`
+	Size    int64           `json:"size"`
+	Results []*bench.Result `json:"results"`
+}
+
+// imageSource abstracts where game images are listed and opened from
+type imageSource interface {
+	List(dir string) ([]gameImage, error)
+	Open(path string) (io.ReaderAt, int64, func() error, error)
+}
+
+type gameImage struct {
+	Path string
+	Size int64
+}
+
+func runBench(query string) error {
+	if err := outputOpts.Validate(); err != nil {
+		return err
+	}
+
+	patterns := bench.Patterns
+	if len(benchPatterns) > 0 {
+		patterns = nil
+		for _, name := range benchPatterns {
+			p, err := bench.FindPattern(name)
+			if err != nil {
+				return err
+			}
+			patterns = append(patterns, p)
+		}
+	}
+
+	if !config.Exists() {
+		return fmt.Errorf("ps2smb is not configured yet. Please run 'sudo ps2smb init' first")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	var src imageSource
+	var where string
+	loopback := false
+	if benchLocal {
+		src = localSource(cfg.GamesPath)
+		where = cfg.GamesPath
+	} else {
+		host := benchHost
+		if host == "" {
+			if host, err = advertisedIP(cfg); err != nil {
+				return fmt.Errorf("failed to detect server address: %v", err)
+			}
+		}
+
+		user := smb1.GuestUser
+		if !cfg.UseGuest {
+			user = cfg.SambaUser
+		}
+		conn, err := smb1.Connect(host, cfg.ShareName, user, os.Getenv("PS2SMB_PASSWORD"), 10*time.Second)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %v", network.FormatSMBPath(host, cfg.ShareName), err)
+		}
+		defer conn.Close()
+
+		src = smbSource{conn}
+		where = network.FormatSMBPath(host, cfg.ShareName)
+		loopback = isOwnAddress(host)
+	}
+
+	game, err := findBenchGame(src, query)
+	if err != nil {
+		return err
+	}
+
+	r, size, closeFn, err := src.Open(game.Path)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	_, direct := r.(*directio.File)
+
+	report := benchReport{Game: game.Path, Source: where, Size: size}
+	if !outputOpts.Structured() {
+		fmt.Printf("Benchmarking %s (%s) from %s\n", game.Path, humanSize(size), where)
+		if benchLocal && !direct {
+			fmt.Println("Note: the file system does not support direct I/O; reads may come from the page cache.")
+		}
+		if loopback {
+			fmt.Println("Note: this server is reached over loopback, so the PS2's network link is not measured")
+			fmt.Println("and Samba may answer from the page cache. Use --local to measure the disk alone.")
+		}
+		fmt.Println()
+		fmt.Printf("%-12s %8s %10s %9s %9s %9s %9s\n", "Pattern", "Reads", "MiB/s", "p50 ms", "p90 ms", "p99 ms", "max ms")
+	}
+
+	for _, p := range patterns {
+		res, err := bench.Run(r, size, p, benchDuration)
+		if err != nil {
+			return fmt.Errorf("%s: %v", p.Name, err)
+		}
+		report.Results = append(report.Results, res)
+
+		if !outputOpts.Structured() {
+			fmt.Printf("%-12s %8d %10.1f %9.3f %9.3f %9.3f %9.3f\n", res.Pattern, res.Reads, res.Throughput,
+				res.Latency.P50, res.Latency.P90, res.Latency.P99, res.Latency.Max)
+		}
+	}
+
+	if outputOpts.Structured() {
+		return render(report)
+	}
+
+	fmt.Println()
+	if benchLocal {
+		fmt.Println("Compare with 'ps2smb bench' without --local: if SMB is much slower, the disk is not the bottleneck.")
+	} else {
+		fmt.Println("Compare with 'ps2smb bench --local', which reads the disk without the page cache.")
+	}
+	return nil
+}
+
+// isOwnAddress reports whether host is a loopback address or one of this
+// machine's own addresses
+func isOwnAddress(host string) bool {
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return false
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, ip := range ips {
+		if ip.IsLoopback() {
+			return true
+		}
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.Equal(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// advertisedIP returns the address 'ps2smb info' gives to the PS2
+func advertisedIP(cfg *config.Config) (string, error) {
+	if cfg.BindInterface != "" {
+		return network.GetIPFromInterface(cfg.BindInterface)
+	}
+	return network.GetLocalIP()
+}
+
+// findBenchGame picks the image matching query in DVD/ and CD/, or the
+// largest image when query is empty
+func findBenchGame(src imageSource, query string) (*gameImage, error) {
+	var images []gameImage
+	for _, dir := range []string{"DVD", "CD"} {
+		found, err := src.List(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %v", dir, err)
+		}
+		images = append(images, found...)
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no ISO images found in DVD/ or CD/")
+	}
+
+	if query == "" {
+		sort.Slice(images, func(i, j int) bool { return images[i].Size > images[j].Size })
+		return &images[0], nil
+	}
+
+	var matches []gameImage
+	for _, img := range images {
+		name := filepath.Base(img.Path)
+		if strings.EqualFold(name, query) || strings.EqualFold(img.Path, query) {
+			return &img, nil
+		}
+		if strings.Contains(strings.ToLower(name), strings.ToLower(query)) {
+			matches = append(matches, img)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no image matches %q", query)
+	case 1:
+		return &matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = m.Path
+	}
+	return nil, fmt.Errorf("%q matches several images: %s", query, strings.Join(names, ", "))
+}
+
+func isISO(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".iso")
+}
+
+// smbSource reads images over the share, exactly as the PS2 would
+type smbSource struct {
+	conn *smb1.Conn
+}
+
+func (s smbSource) List(dir string) ([]gameImage, error) {
+	files, err := s.conn.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	var images []gameImage
+	for _, f := range files {
+		if !f.IsDir && isISO(f.Name) {
+			images = append(images, gameImage{Path: dir + "/" + f.Name, Size: f.Size})
+		}
+	}
+	return images, nil
+}
+
+func (s smbSource) Open(path string) (io.ReaderAt, int64, func() error, error) {
+	f, err := s.conn.Open(path)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return f, f.Size(), f.Close, nil
+}
+
+// localSource reads images straight from the games directory
+type localSource string
+
+func (s localSource) List(dir string) ([]gameImage, error) {
+	entries, err := os.ReadDir(filepath.Join(string(s), dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var images []gameImage
+	for _, e := range entries {
+		if e.IsDir() || !isISO(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		images = append(images, gameImage{Path: dir + "/" + e.Name(), Size: info.Size()})
+	}
+	return images, nil
+}
+
+// Open reads with direct I/O so that repeated runs measure the disk, not
+// the page cache, and falls back to buffered reads where it is unsupported
+func (s localSource) Open(path string) (io.ReaderAt, int64, func() error, error) {
+	full := filepath.Join(string(s), path)
+	if d, err := directio.Open(full); err == nil {
+		return d, d.Size(), d.Close, nil
+	}
+
+	f, err := os.Open(full)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, nil, err
+	}
+	return f, info.Size(), f.Close, nil
+}
+
+// humanSize formats a byte count with binary units
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/matheusc457/ps2smb/internal/library"
+	"github.com/matheusc457/ps2smb/internal/titledb"
+	"github.com/spf13/cobra"
+)
+
+var dbReplace bool
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the offline game title database",
+	Long: `The title database maps game IDs to titles, regions and languages. It is
+used by 'games list', 'games rename', 'games add' and 'info' and is stored
+in ~/.config/ps2smb/titles.json. Nothing is downloaded: import a file
+exported from another tool with 'ps2smb db import'.`,
+}
+
+var dbImportCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Import titles from a CSV, XML or PCSX2 GameIndex file",
+	Long: `Reads game IDs and titles from FILE and merges them into the database.
+Entries for IDs already known are replaced. Supported formats:
+
+  CSV/TSV    ID and title columns, with an optional header naming them
+             (id/serial, title/name, region, languages); OPL Manager and
+             spreadsheet exports work
+  XML        GameTDB ps2tdb.xml, or a Redump/Logiqx DAT with <serial>
+  YAML       PCSX2 GameIndex.yaml
+
+IDs may be written as SLUS-20312, SLUS_203.12 or SLUS20312.`,
+	Example: `  ps2smb db import ps2tdb.xml
+  ps2smb db import --replace titles.csv`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDBImport(args[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show what the database holds and how much of the library it covers",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDBStatus(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var dbLookupCmd = &cobra.Command{
+	Use:   "lookup ID...",
+	Short: "Look up game IDs in the database",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDBLookup(args); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbImportCmd, dbStatusCmd, dbLookupCmd)
+	dbImportCmd.Flags().BoolVar(&dbReplace, "replace", false, "Drop existing entries before importing")
+	addOutputFlags(dbStatusCmd)
+	addOutputFlags(dbLookupCmd)
+}
+
+func runDBImport(file string) error {
+	parsed, err := titledb.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	db, err := titledb.Load()
+	if err != nil {
+		return err
+	}
+	if dbReplace {
+		db.Reset()
+	}
+
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		abs = file
+	}
+	res := db.Merge(titledb.Source{File: abs, Format: parsed.Format, Imported: time.Now()}, parsed.Entries)
+	if err := db.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Imported %d title(s) from %s (%s)\n", len(parsed.Entries), file, parsed.Format)
+	fmt.Printf("  Added: %d  Updated: %d  Unchanged: %d\n", res.Added, res.Updated, res.Unchanged)
+	if parsed.Skipped > 0 {
+		fmt.Printf("  Skipped %d record(s) without a valid game ID or title\n", parsed.Skipped)
+	}
+	fmt.Printf("  The database now knows %d game ID(s)\n", db.Len())
+	return nil
+}
+
+// dbStatusReport is the structured form of 'ps2smb db status'
+type dbStatusReport struct {
+	Path     string           `json:"path"`
+	Entries  int              `json:"entries"`
+	Sources  []titledb.Source `json:"sources"`
+	Coverage *dbCoverage      `json:"library,omitempty"`
+}
+
+// dbCoverage counts the library games the database has a title for
+type dbCoverage struct {
+	Games   int      `json:"games"`
+	Known   int      `json:"known"`
+	Unknown []string `json:"unknown_ids,omitempty"`
+}
+
+// titleCoverage checks which identified games have a database title
+func titleCoverage(cat *library.Catalog) *dbCoverage {
+	c := &dbCoverage{}
+	for _, g := range cat.Games {
+		if g.ID == "" {
+			continue
+		}
+		c.Games++
+		if g.DBTitle != "" {
+			c.Known++
+		} else {
+			c.Unknown = append(c.Unknown, g.ID)
+		}
+	}
+	return c
+}
+
+func runDBStatus() error {
+	if err := outputOpts.Validate(); err != nil {
+		return err
+	}
+
+	db, err := titledb.Load()
+	if err != nil {
+		return err
+	}
+	path, err := titledb.Path()
+	if err != nil {
+		return err
+	}
+
+	report := dbStatusReport{Path: path, Entries: db.Len(), Sources: db.Sources}
+	if report.Sources == nil {
+		report.Sources = []titledb.Source{}
+	}
+	// The library is optional here: the database works before 'init'
+	if _, cat, err := loadCatalog(); err == nil {
+		report.Coverage = titleCoverage(cat)
+	}
+
+	if outputOpts.Structured() {
+		return render(report)
+	}
+
+	fmt.Printf("Title database: %s\n", path)
+	if db.Len() == 0 {
+		fmt.Println("  Empty. Import a title list with: ps2smb db import FILE")
+		return nil
+	}
+	fmt.Printf("  Game IDs: %d\n", db.Len())
+	fmt.Println()
+	fmt.Println("Imported from:")
+	for _, s := range db.Sources {
+		fmt.Printf("  %s (%s, %d entries, %s)\n", s.File, s.Format, s.Entries, s.Imported.Format("2006-01-02 15:04"))
+	}
+
+	if c := report.Coverage; c != nil {
+		fmt.Println()
+		fmt.Printf("Library: %d of %d identified game(s) have a title\n", c.Known, c.Games)
+		for _, id := range c.Unknown {
+			fmt.Printf("  ✗ %s not in the database\n", id)
+		}
+	}
+	return nil
+}
+
+// dbLookupResult is one entry of 'ps2smb db lookup'
+type dbLookupResult struct {
+	ID    string             `json:"id"`
+	Found bool               `json:"found"`
+	Info  *library.TitleInfo `json:"info,omitempty"`
+}
+
+func runDBLookup(args []string) error {
+	if err := outputOpts.Validate(); err != nil {
+		return err
+	}
+
+	db, err := titledb.Load()
+	if err != nil {
+		return err
+	}
+
+	var results []dbLookupResult
+	for _, arg := range args {
+		id, ok := library.NormalizeGameID(arg)
+		if !ok {
+			return fmt.Errorf("%q is not a game ID", arg)
+		}
+		r := dbLookupResult{ID: id}
+		if info, ok := db.Lookup(id); ok {
+			r.Found, r.Info = true, &info
+		}
+		results = append(results, r)
+	}
+
+	if outputOpts.Structured() {
+		return render(results)
+	}
+
+	for _, r := range results {
+		if !r.Found {
+			fmt.Printf("%s  (not in the database)\n", r.ID)
+			continue
+		}
+		fmt.Printf("%s  %s\n", r.ID, r.Info.Title)
+		if r.Info.Region != "" {
+			fmt.Printf("  Region: %s\n", r.Info.Region)
+		}
+		if r.Info.Languages != "" {
+			fmt.Printf("  Languages: %s\n", r.Info.Languages)
+		}
+	}
+	return nil
+}
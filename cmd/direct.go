@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/dhcp"
+	"github.com/matheusc457/ps2smb/internal/network"
+	"github.com/matheusc457/ps2smb/internal/samba"
+	"github.com/spf13/cobra"
+)
+
+// defaultLinkAddress is this PC's address on a dedicated PS2 link
+const defaultLinkAddress = "192.168.50.1/24"
+
+var (
+	directInterface string
+	directAddress   string
+)
+
+var directCmd = &cobra.Command{
+	Use:   "direct",
+	Short: "Serve a PS2 connected directly to a dedicated network card",
+	Long: `Sets up a direct cable link to the PS2: assigns a static address to the
+given interface and runs a built-in DHCP server bound only to that interface,
+so OPL can be left on DHCP. The PS2's MAC address is logged when it requests
+a lease. Press Ctrl-C to stop; the address is removed again on exit.`,
+	Example: `  sudo ps2smb direct --interface enp3s0
+  sudo ps2smb direct --interface enp3s0 --address 10.0.50.1/24`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDirect(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(directCmd)
+	directCmd.Flags().StringVarP(&directInterface, "interface", "i", "", "Network interface the PS2 is plugged into (required)")
+	directCmd.Flags().StringVarP(&directAddress, "address", "a", defaultLinkAddress, "Address for this PC on the direct link")
+	directCmd.MarkFlagRequired("interface")
+}
+
+func runDirect() error {
+	if !samba.IsRoot() {
+		return fmt.Errorf("this command requires root privileges. Please run with sudo")
+	}
+
+	iface, err := network.GetInterface(directInterface)
+	if err != nil {
+		return fmt.Errorf("interface %s not found: %v", directInterface, err)
+	}
+	if route, err := network.DefaultRoute(); err == nil && route.Iface == iface.Name {
+		return fmt.Errorf("%s carries the default route; use a dedicated network card for the PS2", iface.Name)
+	}
+
+	ip, ipnet, err := net.ParseCIDR(directAddress)
+	if err != nil || ip.To4() == nil {
+		return fmt.Errorf("invalid address %q, expected IPv4 CIDR like 192.168.50.1/24", directAddress)
+	}
+	prefix, _ := ipnet.Mask.Size()
+	subnet := network.NewSubnet(network.Address{IP: ip, PrefixLen: prefix})
+
+	// A small pool starting at the suggested address is plenty for one console
+	poolStart, poolEnd, err := subnet.Pool(10)
+	if err != nil {
+		return fmt.Errorf("subnet %s has no room for the PS2", subnet)
+	}
+
+	fmt.Println("PS2SMB Direct Connection")
+	fmt.Println("========================")
+	fmt.Println()
+
+	added := false
+	if !network.HasAddress(iface.Name, directAddress) {
+		fmt.Printf("Assigning %s to %s...\n", directAddress, iface.Name)
+		if err := network.AddAddress(iface.Name, directAddress); err != nil {
+			return err
+		}
+		added = true
+	}
+	defer func() {
+		if added {
+			fmt.Printf("Removing %s from %s...\n", directAddress, iface.Name)
+			if err := network.DelAddress(iface.Name, directAddress); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}
+	}()
+
+	if !iface.Carrier {
+		fmt.Printf("Warning: no cable detected on %s yet\n", iface.Name)
+	}
+
+	server := &dhcp.Server{
+		Interface: iface.Name,
+		ServerIP:  ip.To4(),
+		Mask:      subnet.Mask,
+		Router:    ip.To4(),
+		PoolStart: poolStart,
+		PoolEnd:   poolEnd,
+		LeaseTime: 12 * time.Hour,
+		Logf: func(format string, args ...any) {
+			fmt.Printf("[%s] %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+		},
+		OnLease: func(lease dhcp.Lease) {
+			name := ""
+			if lease.Hostname != "" {
+				name = fmt.Sprintf(" (%s)", lease.Hostname)
+			}
+			fmt.Printf("[%s] PS2 connected: MAC %s%s leased %s\n",
+				time.Now().Format("15:04:05"), lease.MAC, name, lease.IP)
+		},
+	}
+
+	shareName := samba.ShareName
+	if cfg, err := config.Load(); err == nil {
+		shareName = cfg.ShareName
+	}
+
+	fmt.Println()
+	fmt.Println("Configure on your PS2 (OPL):")
+	fmt.Println("   - IP address type: DHCP")
+	fmt.Println("   - Address type: IP")
+	fmt.Printf("   - Address: %s\n", network.FormatOPL(ip))
+	fmt.Printf("   - Share: %s\n", shareName)
+	fmt.Println("   - Ethernet operation mode: 100Mbit half-duplex if the link is unstable")
+	fmt.Println()
+	fmt.Printf("DHCP server running on %s (pool %s - %s). Press Ctrl-C to stop.\n",
+		iface.Name, poolStart, poolEnd)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := server.Serve(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("Stopping DHCP server...")
+	return nil
+}
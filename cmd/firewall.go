@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/firewall"
+	"github.com/matheusc457/ps2smb/internal/network"
+	"github.com/matheusc457/ps2smb/internal/samba"
+	"github.com/spf13/cobra"
+)
+
+var firewallSource string
+
+var firewallCmd = &cobra.Command{
+	Use:   "firewall",
+	Short: "Inspect and manage firewall rules for SMB",
+	Long: `Detects ufw, firewalld, nftables or iptables and manages rules for the ports
+OPL needs: UDP 137-138 (NetBIOS) and TCP 139/445 (SMB). Rules are scoped to the
+PS2 subnet by default; use --source any to allow every host.`,
+	Example: `  sudo ps2smb firewall status
+  sudo ps2smb firewall open
+  sudo ps2smb firewall open --source 192.168.1.50
+  sudo ps2smb firewall close`,
+}
+
+var firewallStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether SMB traffic from the PS2 subnet is allowed",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runFirewall("status"); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var firewallOpenCmd = &cobra.Command{
+	Use:   "open",
+	Short: "Allow SMB traffic from the PS2 subnet",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runFirewall("open"); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var firewallCloseCmd = &cobra.Command{
+	Use:   "close",
+	Short: "Remove the rules added by 'firewall open'",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runFirewall("close"); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(firewallCmd)
+	firewallCmd.AddCommand(firewallStatusCmd, firewallOpenCmd, firewallCloseCmd)
+	firewallCmd.PersistentFlags().StringVarP(&firewallSource, "source", "s", "", "Subnet or address to allow, or 'any' (default: the PS2 subnet)")
+}
+
+// firewallScope resolves --source, defaulting to the subnet of the bound
+// interface or the auto-selected one
+func firewallScope() (string, error) {
+	switch firewallSource {
+	case "any":
+		return "", nil
+	case "":
+		iface := ""
+		if cfg, err := config.Load(); err == nil {
+			iface = cfg.BindInterface
+		}
+		subnet, err := network.PS2Subnet(iface)
+		if err != nil {
+			return "", fmt.Errorf("failed to determine the PS2 subnet, use --source: %v", err)
+		}
+		return subnet.String(), nil
+	}
+	return firewallSource, nil
+}
+
+func runFirewall(action string) error {
+	if !samba.IsRoot() {
+		return fmt.Errorf("this command requires root privileges. Please run with sudo")
+	}
+
+	fw, err := firewall.Detect()
+	if err != nil {
+		return err
+	}
+	if fw == nil {
+		fmt.Println("No active firewall detected; SMB traffic is not filtered.")
+		return nil
+	}
+
+	source, err := firewallScope()
+	if err != nil {
+		return err
+	}
+	scope := source
+	if scope == "" {
+		scope = "any host"
+	}
+
+	fmt.Printf("Firewall: %s\n", fw.Name())
+	fmt.Printf("Source: %s\n", scope)
+	fmt.Println()
+
+	switch action {
+	case "open":
+		if err := fw.Open(source); err != nil {
+			return fmt.Errorf("failed to open SMB ports: %v", err)
+		}
+		fmt.Println("SMB ports opened.")
+		if !fw.Persistent() {
+			fmt.Println("Note: these rules are not persistent; save your ruleset to keep them after a reboot.")
+		}
+		return nil
+	case "close":
+		if err := fw.Close(source); err != nil {
+			return fmt.Errorf("failed to close SMB ports: %v", err)
+		}
+		fmt.Println("SMB rules removed.")
+		return nil
+	}
+
+	allowed, err := fw.Allowed(source)
+	if err != nil {
+		return fmt.Errorf("failed to inspect firewall: %v", err)
+	}
+	for _, p := range firewall.SMBPorts {
+		fmt.Printf("%-8s ", p)
+		printStatus(allowed[p])
+	}
+	if !firewall.AllAllowed(allowed) {
+		fmt.Println("\nRun 'sudo ps2smb firewall open' to allow them.")
+	}
+	return nil
+}
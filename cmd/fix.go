@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/matheusc457/ps2smb/internal/health"
+	"github.com/matheusc457/ps2smb/internal/samba"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fixYes     bool
+	fixNetBIOS bool
+)
+
+// errDeclined records a remedy the user chose not to apply
+var errDeclined = errors.New("declined")
+
+var fixCmd = &cobra.Command{
+	Use:   "fix",
+	Short: "Repair problems reported by status",
+	Long: `Runs the same checks as 'ps2smb status' and applies the automated repair
+for each failed check: creating missing directories, starting and enabling
+the Samba service, rewriting the PS2 share, restoring global settings and
+opening the firewall. With --netbios, the NetBIOS name and nmbd are checked
+and repaired too. Each repair is confirmed individually unless --yes is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runFix(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fixCmd)
+	fixCmd.Flags().BoolVarP(&fixYes, "yes", "y", false, "Apply all repairs without asking")
+	fixCmd.Flags().BoolVar(&fixNetBIOS, "netbios", false, "Also repair the NetBIOS name and nmbd")
+}
+
+func runFix() error {
+	if !samba.IsRoot() {
+		return fmt.Errorf("this command requires root privileges. Please run with sudo")
+	}
+
+	report, err := health.Run(health.Options{NetBIOS: fixNetBIOS})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("PS2SMB Fix")
+	fmt.Println("==========")
+	fmt.Println()
+
+	// Outcome of each remedy offered, since several findings can share one
+	applied := make(map[string]error)
+	fixed, failed, skipped, manual := 0, 0, 0, 0
+
+	for _, f := range report.Findings {
+		if f.OK() {
+			continue
+		}
+
+		fmt.Printf("✗ %s\n", f.Check)
+		if f.Message != "" {
+			fmt.Printf("  %s\n", f.Message)
+		}
+
+		if f.Remedy == nil {
+			fmt.Printf("  No automatic fix available. %s\n\n", f.Fix)
+			manual++
+			continue
+		}
+		if err, ok := applied[f.Remedy.ID]; ok {
+			if err == errDeclined {
+				fmt.Println("  Skipped above")
+				fmt.Println()
+				continue
+			}
+			if err != nil {
+				fmt.Printf("  Failed above: %v\n\n", err)
+				failed++
+				continue
+			}
+			fmt.Println("  Already repaired above")
+			fmt.Println()
+			continue
+		}
+
+		fmt.Printf("  Fix: %s\n", f.Remedy.Description)
+		if !fixYes && !askYesNo("  Apply this fix?") {
+			applied[f.Remedy.ID] = errDeclined
+			fmt.Println("  Skipped")
+			fmt.Println()
+			skipped++
+			continue
+		}
+
+		err := f.Remedy.Apply()
+		applied[f.Remedy.ID] = err
+		if err != nil {
+			fmt.Printf("  Failed: %v\n\n", err)
+			failed++
+			continue
+		}
+		fmt.Println("  ✓ Fixed")
+		fmt.Println()
+		fixed++
+	}
+
+	if fixed+failed+skipped+manual == 0 {
+		fmt.Println("Nothing to fix. All checks passed!")
+		return nil
+	}
+
+	fmt.Println("Summary:")
+	fmt.Println("--------")
+	fmt.Printf("Fixed: %d  Failed: %d  Skipped: %d  Manual: %d\n", fixed, failed, skipped, manual)
+	fmt.Println("\nRun 'sudo ps2smb status' to verify.")
+
+	if failed > 0 {
+		return fmt.Errorf("%d fix(es) failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/library"
+	"github.com/matheusc457/ps2smb/internal/output"
+	"github.com/matheusc457/ps2smb/internal/titledb"
+	"github.com/spf13/cobra"
+)
+
+var gamesJSON bool
+
+var gamesCmd = &cobra.Command{
+	Use:   "games",
+	Short: "Inspect and manage the game library",
+	Long: `Works on the disc images in the DVD/ and CD/ folders of the games directory.
+Each ISO is parsed (ISO9660 volume descriptor and SYSTEM.CNF) to find its
+game ID and region.`,
+}
+
+var gamesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List games with their ID, title, size and media type",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runGamesList(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gamesCmd)
+	gamesCmd.AddCommand(gamesListCmd)
+	gamesListCmd.Flags().BoolVar(&gamesJSON, "json", false, "Print the catalog as JSON (same as --output json)")
+	addOutputFlags(gamesListCmd)
+}
+
+// loadCatalog scans the configured games directory and looks up titles in
+// the title database
+func loadCatalog() (*config.Config, *library.Catalog, error) {
+	if !config.Exists() {
+		return nil, nil, fmt.Errorf("ps2smb is not configured yet. Please run 'sudo ps2smb init' first")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	cat, err := library.Scan(cfg.GamesPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The title database is optional; a damaged one only costs the titles
+	db, err := titledb.Load()
+	if err != nil {
+		if !outputOpts.Structured() {
+			fmt.Printf("Warning: %v; continuing without database titles\n", err)
+		}
+		return cfg, cat, nil
+	}
+	if db.Len() > 0 {
+		cat.SetTitles(db.Lookup)
+	}
+	return cfg, cat, nil
+}
+
+func runGamesList() error {
+	if gamesJSON {
+		outputOpts.Format = output.FormatJSON
+	}
+	if err := outputOpts.Validate(); err != nil {
+		return err
+	}
+
+	_, cat, err := loadCatalog()
+	if err != nil {
+		return err
+	}
+
+	if outputOpts.Structured() {
+		return render(cat)
+	}
+
+	if len(cat.Games) == 0 {
+		fmt.Printf("No games found in %s/DVD or %s/CD\n", cat.Root, cat.Root)
+		return nil
+	}
+
+	titleWidth := len("Title")
+	for _, g := range cat.Games {
+		titleWidth = max(titleWidth, min(len(g.DisplayTitle()), 48))
+	}
+
+	fmt.Printf("Games in %s (%d)\n", cat.Root, len(cat.Games))
+	fmt.Println()
+	fmt.Printf("%-11s  %-*s  %-7s  %9s  %s\n", "ID", titleWidth, "Title", "Region", "Size", "Media")
+	for _, g := range cat.Games {
+		id, region := g.ID, string(g.Region)
+		if id == "" {
+			id, region = "-", "-"
+		}
+		media := string(g.Media)
+		if g.Compressed {
+			media += " (ZSO)"
+		}
+		fmt.Printf("%-11s  %-*s  %-7s  %9s  %s\n", id, titleWidth, truncate(g.DisplayTitle(), titleWidth), region, humanSize(g.Size), media)
+	}
+
+	if misplaced := cat.Misplaced(); len(misplaced) > 0 {
+		fmt.Println()
+		fmt.Printf("%d image(s) in the wrong folder:\n", len(misplaced))
+		for _, g := range misplaced {
+			fmt.Printf("  %s is a %s image (%s)\n", g.RelPath(), g.Detected, g.DetectedBy)
+		}
+		fmt.Println("Move them with: sudo ps2smb games sort")
+	}
+
+	if bad := cat.Errors(); len(bad) > 0 {
+		fmt.Println()
+		fmt.Printf("%d image(s) could not be read:\n", len(bad))
+		for _, g := range bad {
+			fmt.Printf("  %s: %s\n", g.RelPath(), g.Error)
+		}
+	}
+	return nil
+}
+
+// truncate shortens s to n bytes, marking the cut with "..."
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n <= 3 {
+		return s[:n]
+	}
+	return s[:n-3] + "..."
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/library"
+	"github.com/spf13/cobra"
+)
+
+var gamesAddCmd = &cobra.Command{
+	Use:   "add FILE...",
+	Short: "Import disc images into the library",
+	Long: `Copies each image into the games directory ready for OPL:
+
+  - the game ID is read from the disc and duplicates are refused
+  - CD or DVD is detected and the right folder is chosen
+  - the file is named SLUS_XXX.XX.Title.iso, using the title database
+    when it knows the game
+  - the copy is checksummed (SHA-1) while writing and verified afterwards
+  - the file is made readable by the share user
+
+BIN/CUE images (2352-byte raw sectors, as many CD games are distributed)
+are converted to ISO while copying. Pass the .cue, or a .bin with its .cue
+next to it. Discs with several tracks cannot be converted.
+
+Copies go to a .part file first; if one is interrupted, running the same
+command again resumes it.`,
+	Example: `  sudo ps2smb games add ~/Downloads/*.iso
+  sudo ps2smb games add "Game (USA).cue"`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runGamesAdd(args); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	gamesCmd.AddCommand(gamesAddCmd)
+}
+
+func runGamesAdd(files []string) error {
+	cfg, cat, err := loadCatalog()
+	if err != nil {
+		return err
+	}
+
+	opts := library.AddOptions{UID: -1, GID: -1}
+	if uid, gid, ok := shareOwner(cfg); ok {
+		opts.UID, opts.GID = uid, gid
+	}
+
+	// A .bin listed next to its .cue (as from a glob) is the same disc
+	given := make(map[string]bool)
+	for _, file := range files {
+		given[file] = true
+	}
+
+	added, failed := 0, 0
+	for _, file := range files {
+		if strings.EqualFold(filepath.Ext(file), ".bin") && given[strings.TrimSuffix(file, filepath.Ext(file))+".cue"] {
+			continue
+		}
+		fmt.Printf("%s\n", filepath.Base(file))
+
+		progress := newProgressLine()
+		opts.Progress = progress.update
+		res, err := library.Add(cat, file, opts)
+		progress.finish()
+
+		if err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			failed++
+			continue
+		}
+
+		if res.Converted != "" {
+			fmt.Printf("  Converted from %s to ISO\n", res.Converted)
+		}
+		if res.Resumed > 0 {
+			fmt.Printf("  Resumed after %s\n", humanSize(res.Resumed))
+		}
+		fmt.Printf("  ✓ %s/%s (%s, %s)\n", res.Game.Media, res.Game.File, res.Game.ID, res.Game.DetectedBy)
+		fmt.Printf("    SHA-1 %s verified\n", res.SHA1)
+		added++
+	}
+
+	fmt.Println()
+	fmt.Printf("Added: %d  Failed: %d\n", added, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d image(s) could not be added", failed)
+	}
+	return nil
+}
+
+// shareOwner returns the Samba user's IDs in user mode, so imported files
+// belong to the account that reads them over the share
+func shareOwner(cfg *config.Config) (int, int, bool) {
+	if cfg.UseGuest || cfg.SambaUser == "" {
+		return 0, 0, false
+	}
+	u, err := user.Lookup(cfg.SambaUser)
+	if err != nil {
+		return 0, 0, false
+	}
+	uid, err1 := strconv.Atoi(u.Uid)
+	gid, err2 := strconv.Atoi(u.Gid)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return uid, gid, true
+}
+
+// progressLine redraws a single status line at most a few times a second
+type progressLine struct {
+	phase   string
+	start   time.Time
+	last    time.Time
+	printed bool
+}
+
+func newProgressLine() *progressLine {
+	return &progressLine{}
+}
+
+func (p *progressLine) update(phase string, done, total int64) {
+	now := time.Now()
+	if phase != p.phase {
+		if p.printed {
+			fmt.Println()
+		}
+		p.phase, p.start, p.printed = phase, now, false
+	}
+	if done < total && now.Sub(p.last) < 250*time.Millisecond {
+		return
+	}
+	p.last = now
+
+	pct := 100.0
+	if total > 0 {
+		pct = float64(done) * 100 / float64(total)
+	}
+	rate := ""
+	if secs := now.Sub(p.start).Seconds(); secs > 0 {
+		rate = fmt.Sprintf(", %s/s", humanSize(int64(float64(done)/secs)))
+	}
+	fmt.Printf("\r  %-6s %5.1f%% (%s of %s%s)   ", p.phase, pct, humanSize(done), humanSize(total), rate)
+	p.printed = true
+}
+
+func (p *progressLine) finish() {
+	if p.printed {
+		fmt.Println()
+	}
+}
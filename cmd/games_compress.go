@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/matheusc457/ps2smb/internal/library"
+	"github.com/spf13/cobra"
+)
+
+var (
+	convertAll  bool
+	convertKeep bool
+)
+
+var gamesCompressCmd = &cobra.Command{
+	Use:   "compress [GAME...]",
+	Short: "Compress ISO images to ZSO",
+	Long: `Compresses images to ZSO (LZ4-compressed ISO), which newer OPL builds load
+directly. Each image is verified by decompressing the ZSO and comparing
+its SHA-1 with the original before the ISO is removed.
+
+GAME is a game ID, a file name or part of one; use --all for every ISO.`,
+	Example: `  sudo ps2smb games compress SLUS_203.12
+  sudo ps2smb games compress --all`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runGamesConvert(args, true); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var gamesDecompressCmd = &cobra.Command{
+	Use:   "decompress [GAME...]",
+	Short: "Decompress ZSO images back to ISO",
+	Long: `Writes the ISO stored in each ZSO image, verifies it and removes the ZSO.
+Older OPL builds and other tools only read ISO images.
+
+GAME is a game ID, a file name or part of one; use --all for every ZSO.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runGamesConvert(args, false); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{gamesCompressCmd, gamesDecompressCmd} {
+		gamesCmd.AddCommand(c)
+		c.Flags().BoolVar(&convertAll, "all", false, "Convert every image in the library")
+		c.Flags().BoolVar(&convertKeep, "keep", false, "Keep the original image")
+	}
+}
+
+func runGamesConvert(args []string, compress bool) error {
+	if len(args) == 0 && !convertAll {
+		return fmt.Errorf("name the games to convert or pass --all")
+	}
+
+	_, cat, err := loadCatalog()
+	if err != nil {
+		return err
+	}
+
+	// Only images in the source format are candidates
+	var candidates []library.Game
+	for _, g := range cat.Games {
+		if g.Compressed != compress && g.Error == "" {
+			candidates = append(candidates, g)
+		}
+	}
+
+	games := candidates
+	if !convertAll {
+		if games, err = selectGames(candidates, args); err != nil {
+			return err
+		}
+	}
+	if len(games) == 0 {
+		if compress {
+			fmt.Println("No ISO images to compress.")
+		} else {
+			fmt.Println("No ZSO images to decompress.")
+		}
+		return nil
+	}
+
+	convert, phase := library.CompressImage, "compress"
+	if !compress {
+		convert, phase = library.DecompressImage, "decompress"
+	}
+
+	var saved int64
+	done, failed := 0, 0
+	for _, g := range games {
+		fmt.Printf("%s\n", g.RelPath())
+
+		progress := newProgressLine()
+		res, err := convert(g, library.ConvertOptions{Progress: progress.update, Keep: convertKeep})
+		progress.finish()
+
+		if err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			failed++
+			continue
+		}
+
+		fmt.Printf("  ✓ %s/%s: %s -> %s", g.Media, filepath.Base(res.Dest), humanSize(res.OldSize), humanSize(res.NewSize))
+		if compress && res.OldSize > 0 {
+			fmt.Printf(" (%.0f%%)", float64(res.NewSize)*100/float64(res.OldSize))
+		}
+		fmt.Println()
+		fmt.Printf("    SHA-1 %s verified\n", res.SHA1)
+		saved += res.OldSize - res.NewSize
+		done++
+	}
+
+	fmt.Println()
+	fmt.Printf("Converted: %d  Failed: %d", done, failed)
+	if compress && !convertKeep && saved > 0 {
+		fmt.Printf("  Saved: %s", humanSize(saved))
+	}
+	fmt.Println()
+	if failed > 0 {
+		return fmt.Errorf("%d image(s) could not be %sed", failed, phase)
+	}
+	return nil
+}
+
+// selectGames resolves each argument to one game: an exact game ID or file
+// name, or a unique part of a file name
+func selectGames(games []library.Game, args []string) ([]library.Game, error) {
+	var selected []library.Game
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		var matches []library.Game
+		for _, g := range games {
+			if strings.EqualFold(g.ID, arg) || strings.EqualFold(g.File, arg) || strings.EqualFold(g.RelPath(), arg) {
+				matches = []library.Game{g}
+				break
+			}
+			if strings.Contains(strings.ToLower(g.File), strings.ToLower(arg)) {
+				matches = append(matches, g)
+			}
+		}
+
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("no image matches %q", arg)
+		case 1:
+		default:
+			names := make([]string, len(matches))
+			for i, m := range matches {
+				names[i] = m.RelPath()
+			}
+			return nil, fmt.Errorf("%q matches several images: %s", arg, strings.Join(names, ", "))
+		}
+
+		if !seen[matches[0].Path] {
+			seen[matches[0].Path] = true
+			selected = append(selected, matches[0])
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/matheusc457/ps2smb/internal/library"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dupesRemove  bool
+	dupesLink    bool
+	dupesYes     bool
+	dupesJobs    int
+	dupesNoCache bool
+)
+
+var gamesDupesCmd = &cobra.Command{
+	Use:     "dupes",
+	Aliases: []string{"duplicates"},
+	Short:   "Find duplicate images, revisions and region variants",
+	Long: `Groups the library by game ID and by content hash (SHA-1 of the disc data, so
+an ISO and a ZSO of the same dump match) and reports:
+
+  duplicates  identical images stored more than once
+  revisions   images with the same game ID but different content
+  variants    the same title under several game IDs, usually regions
+
+Only images that share a game ID are hashed; hashes are cached like
+'games verify'. For each set of duplicates one image is kept: the one in
+the right CD/DVD folder with an OPL name. --remove deletes the other
+copies and --link replaces them by hard links to the kept image, so every
+name still works but the data is stored once.`,
+	Example: `  ps2smb games dupes
+  sudo ps2smb games dupes --remove
+  sudo ps2smb games dupes --link`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runGamesDupes(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	gamesCmd.AddCommand(gamesDupesCmd)
+	gamesDupesCmd.Flags().BoolVar(&dupesRemove, "remove", false, "Delete the extra copies of each duplicate")
+	gamesDupesCmd.Flags().BoolVar(&dupesLink, "link", false, "Replace the extra copies by hard links to the kept image")
+	gamesDupesCmd.Flags().BoolVarP(&dupesYes, "yes", "y", false, "Do not ask for confirmation")
+	gamesDupesCmd.Flags().IntVarP(&dupesJobs, "jobs", "j", min(runtime.NumCPU(), 4), "Images to hash in parallel")
+	gamesDupesCmd.Flags().BoolVar(&dupesNoCache, "no-cache", false, "Hash every image again")
+	addOutputFlags(gamesDupesCmd)
+}
+
+func runGamesDupes() error {
+	if err := outputOpts.Validate(); err != nil {
+		return err
+	}
+	if dupesRemove && dupesLink {
+		return fmt.Errorf("--remove and --link cannot be combined")
+	}
+	if outputOpts.Structured() && (dupesRemove || dupesLink) {
+		return fmt.Errorf("--output cannot be combined with --remove or --link")
+	}
+
+	_, cat, err := loadCatalog()
+	if err != nil {
+		return err
+	}
+
+	opts := library.HashOptions{Jobs: dupesJobs}
+	cachePath, err := hashCachePath()
+	if err == nil && !dupesNoCache {
+		opts.Cache = library.LoadHashCache(cachePath)
+	}
+
+	var progress *progressLine
+	if !outputOpts.Structured() {
+		fmt.Printf("Checking %d image(s) in %s\n", len(cat.Games), cat.Root)
+		progress = newProgressLine()
+		opts.Progress = func(done, total int64) { progress.update("hash", done, total) }
+	}
+
+	report := library.FindDuplicates(cat, opts)
+	if progress != nil {
+		progress.finish()
+	}
+
+	if opts.Cache != nil {
+		if err := opts.Cache.Save(); err != nil && !outputOpts.Structured() {
+			fmt.Printf("Warning: failed to save hash cache: %v\n", err)
+		}
+	}
+
+	if outputOpts.Structured() {
+		if report.Duplicates == nil {
+			report.Duplicates = []library.DuplicateSet{}
+		}
+		if report.Revisions == nil {
+			report.Revisions = []library.ImageGroup{}
+		}
+		if report.Variants == nil {
+			report.Variants = []library.ImageGroup{}
+		}
+		return render(report)
+	}
+
+	fmt.Println()
+	printDupes(report)
+
+	copies, linkable := 0, 0
+	for _, set := range report.Duplicates {
+		for _, c := range set.Copies {
+			copies++
+			if c.CanLink && !c.Linked {
+				linkable++
+			}
+		}
+	}
+	if copies == 0 {
+		return nil
+	}
+
+	switch {
+	case dupesRemove:
+		if !dupesYes && !askYesNo(fmt.Sprintf("Delete %d copy(ies)?", copies)) {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	case dupesLink:
+		if linkable == 0 {
+			fmt.Println("No copy can be replaced by a hard link.")
+			return nil
+		}
+		if !dupesYes && !askYesNo(fmt.Sprintf("Replace %d copy(ies) with hard links?", linkable)) {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	default:
+		fmt.Println("Delete the copies with --remove, or keep their names with --link.")
+		return nil
+	}
+
+	n, freed, err := library.ResolveDuplicates(report.Duplicates, dupesLink)
+	verb := "Removed"
+	if dupesLink {
+		verb = "Linked"
+	}
+	if n > 0 {
+		fmt.Printf("✓ %s %d copy(ies), freed %s\n", verb, n, humanSize(freed))
+	}
+	return err
+}
+
+func printDupes(report *library.DuplicateReport) {
+	if len(report.Duplicates) == 0 && len(report.Revisions) == 0 && len(report.Variants) == 0 {
+		fmt.Println("No duplicates, revisions or region variants found.")
+	}
+
+	if len(report.Duplicates) > 0 {
+		fmt.Printf("Duplicates (%d):\n", len(report.Duplicates))
+		for _, set := range report.Duplicates {
+			fmt.Printf("  %s  %s\n", dupeID(set.ID), set.Title)
+			fmt.Printf("    keep  %s\n", set.Keep)
+			for _, c := range set.Copies {
+				note := humanSize(c.Size)
+				switch {
+				case c.Linked:
+					note = "hard link, no extra space"
+				case !c.CanLink:
+					note += ", other format"
+				}
+				fmt.Printf("    copy  %s (%s)\n", c.File, note)
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(report.Revisions) > 0 {
+		fmt.Printf("Revisions, same ID with different content (%d):\n", len(report.Revisions))
+		for _, r := range report.Revisions {
+			fmt.Printf("  %s  %s\n", r.ID, r.Title)
+			for _, img := range r.Images {
+				version := img.Version
+				if version == "" {
+					version = "-"
+				}
+				fmt.Printf("    %-8s  %.12s  %s\n", version, img.SHA1, img.File)
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(report.Variants) > 0 {
+		fmt.Printf("Region variants (%d):\n", len(report.Variants))
+		for _, v := range report.Variants {
+			fmt.Printf("  %s\n", v.Title)
+			for _, img := range v.Images {
+				fmt.Printf("    %-11s  %-7s  %s\n", img.ID, img.Region, img.File)
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(report.Unreadable) > 0 {
+		fmt.Printf("%d image(s) could not be hashed:\n", len(report.Unreadable))
+		for _, img := range report.Unreadable {
+			fmt.Printf("  %s: %s\n", img.File, img.Error)
+		}
+		fmt.Println()
+	}
+
+	if len(report.Duplicates) > 0 {
+		fmt.Printf("Reclaimable: %s\n", humanSize(report.Reclaimable))
+	}
+}
+
+// dupeID shows images without a game ID as "-", like 'games list'
+func dupeID(id string) string {
+	if id == "" {
+		return "-"
+	}
+	return id
+}
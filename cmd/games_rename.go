@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/library"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renameApply bool
+	renameYes   bool
+	renameUndo  bool
+)
+
+var gamesRenameCmd = &cobra.Command{
+	Use:   "rename",
+	Short: "Rename images to OPL's SLUS_XXX.XX.Title.iso format",
+	Long: `Proposes OPL-style file names built from the game ID read from each disc and
+a cleaned-up title: ASCII only, no characters SMB rejects, and at most 64
+characters (OPL skips images with longer titles). When the title database
+knows the game ID (see 'ps2smb db import'), its title is used instead of
+the one in the current file name.
+
+Without --apply only a preview is shown. Renames are applied as a batch: if
+one fails, the others are reverted. Every applied batch is recorded in an
+undo log so it can be reverted with --undo.`,
+	Example: `  ps2smb games rename
+  sudo ps2smb games rename --apply
+  sudo ps2smb games rename --undo`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runGamesRename(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	gamesCmd.AddCommand(gamesRenameCmd)
+	gamesRenameCmd.Flags().BoolVar(&renameApply, "apply", false, "Apply the proposed renames")
+	gamesRenameCmd.Flags().BoolVarP(&renameYes, "yes", "y", false, "Do not ask for confirmation")
+	gamesRenameCmd.Flags().BoolVar(&renameUndo, "undo", false, "Revert the most recent batch of renames")
+	addOutputFlags(gamesRenameCmd)
+}
+
+// undoDir is where rename undo logs are kept
+func undoDir() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %v", err)
+	}
+	return filepath.Join(dir, "undo"), nil
+}
+
+func runGamesRename() error {
+	if err := outputOpts.Validate(); err != nil {
+		return err
+	}
+	if renameUndo {
+		return runRenameUndo()
+	}
+
+	_, cat, err := loadCatalog()
+	if err != nil {
+		return err
+	}
+	plan := library.PlanRenames(cat)
+
+	if outputOpts.Structured() {
+		if renameApply {
+			return fmt.Errorf("--output cannot be combined with --apply")
+		}
+		return render(plan)
+	}
+
+	var shown []library.Rename
+	changes := 0
+	fromWidth := len("Current name")
+	for _, r := range plan {
+		if !r.Changed() && len(r.Problems) == 0 && r.Skip == "" {
+			continue
+		}
+		if r.Changed() {
+			changes++
+		}
+		shown = append(shown, r)
+		fromWidth = max(fromWidth, min(len(r.From), 60))
+	}
+
+	if len(shown) > 0 {
+		fmt.Printf("%-5s  %-*s  %s\n", "Media", fromWidth, "Current name", "New name")
+		for _, r := range shown {
+			to := r.To
+			switch {
+			case r.Skip != "":
+				to = "(skipped: " + r.Skip + ")"
+			case !r.Changed():
+				to = "(unchanged)"
+			}
+			fmt.Printf("%-5s  %-*s  %s\n", r.Media, fromWidth, truncate(r.From, fromWidth), to)
+		}
+	}
+
+	problems := false
+	for _, r := range shown {
+		if len(r.Problems) == 0 {
+			continue
+		}
+		if !problems {
+			fmt.Println()
+			fmt.Println("Problems with current names:")
+			problems = true
+		}
+		fmt.Printf("  %s/%s\n", r.Media, r.From)
+		for _, p := range r.Problems {
+			fmt.Printf("    - %s\n", p)
+		}
+	}
+
+	if changes == 0 {
+		if len(shown) > 0 {
+			fmt.Println()
+		}
+		fmt.Println("No images to rename.")
+		return nil
+	}
+	fmt.Println()
+	fmt.Printf("%d image(s) to rename.\n", changes)
+
+	if !renameApply {
+		fmt.Println("Run with --apply to rename them.")
+		return nil
+	}
+	if !renameYes && !askYesNo("Rename these files?") {
+		fmt.Println("Cancelled")
+		return nil
+	}
+
+	dir, err := undoDir()
+	if err != nil {
+		return err
+	}
+	logPath := filepath.Join(dir, library.UndoLogName(time.Now()))
+
+	n, err := library.ApplyRenames(cat.Root, plan, logPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Renamed %d image(s)\n", n)
+	fmt.Printf("Undo with: ps2smb games rename --undo (log: %s)\n", logPath)
+	return nil
+}
+
+func runRenameUndo() error {
+	dir, err := undoDir()
+	if err != nil {
+		return err
+	}
+	logPath, err := library.LatestUndoLog(dir)
+	if err != nil {
+		return err
+	}
+
+	log, err := library.Undo(logPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Reverted %d rename(s) from %s\n", len(log.Renames), log.Time.Format("2006-01-02 15:04:05"))
+	return nil
+}
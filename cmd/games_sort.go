@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/matheusc457/ps2smb/internal/library"
+	"github.com/spf13/cobra"
+)
+
+var sortYes bool
+
+var gamesSortCmd = &cobra.Command{
+	Use:   "sort",
+	Short: "Move DVD images out of CD/ and CD images out of DVD/",
+	Long: `Classifies each image from its disc structure and size: PS2 DVDs carry a UDF
+bridge and may exceed CD capacity, CDs are plain ISO9660. Images in the
+wrong folder are moved after confirmation; OPL hides or fails to boot them
+otherwise.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runGamesSort(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	gamesCmd.AddCommand(gamesSortCmd)
+	gamesSortCmd.Flags().BoolVarP(&sortYes, "yes", "y", false, "Move without asking for confirmation")
+}
+
+func runGamesSort() error {
+	_, cat, err := loadCatalog()
+	if err != nil {
+		return err
+	}
+
+	moves := library.PlanSort(cat)
+	if len(moves) == 0 {
+		fmt.Println("All images are in the correct folder.")
+		return nil
+	}
+
+	pending := 0
+	for _, m := range moves {
+		fmt.Printf("  %s/%s -> %s/ (%s)\n", m.From, m.File, m.To, m.Why)
+		if m.Skip != "" {
+			fmt.Printf("    skipped: %s\n", m.Skip)
+			continue
+		}
+		pending++
+	}
+	fmt.Println()
+
+	if pending == 0 {
+		return fmt.Errorf("no image can be moved")
+	}
+	if !sortYes && !askYesNo(fmt.Sprintf("Move %d image(s)?", pending)) {
+		fmt.Println("Cancelled")
+		return nil
+	}
+
+	n, err := library.ApplyMoves(cat.Root, moves)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Moved %d image(s)\n", n)
+	return nil
+}
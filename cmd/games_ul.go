@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/matheusc457/ps2smb/internal/library"
+	"github.com/spf13/cobra"
+)
+
+var ulAll bool
+
+var gamesULCmd = &cobra.Command{
+	Use:     "ul",
+	Aliases: []string{"usbextreme"},
+	Short:   "Import and export USBExtreme (ul.cfg) split images",
+	Long: `Works with the USBAdvance/USBExtreme format OPL reads from FAT32 USB drives:
+a ul.cfg index plus each game split into 1 GiB parts named
+ul.<CRC32 of name>.<game ID>.<part>.
+
+DIR is the folder holding ul.cfg, usually the root of the USB drive.`,
+}
+
+var gamesULListCmd = &cobra.Command{
+	Use:   "list DIR",
+	Short: "List the games in a ul.cfg",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runULList(args[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var gamesULImportCmd = &cobra.Command{
+	Use:   "import DIR [GAME...]",
+	Short: "Join USBExtreme parts into ISOs in the library",
+	Long: `Joins the parts of each game into a standard ISO in the games directory. As
+with 'games add', duplicates are refused, the CD or DVD folder is chosen
+from the disc, the file gets an OPL name and the copy is verified.
+
+GAME is a game ID or part of a ul.cfg name; use --all for every game.`,
+	Example: `  sudo ps2smb games ul import /media/usb --all`,
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runULImport(args[0], args[1:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var gamesULExportCmd = &cobra.Command{
+	Use:   "export DIR [GAME...]",
+	Short: "Split library images into USBExtreme parts",
+	Long: `Splits ISO or ZSO images from the library into 1 GiB parts in DIR and adds
+them to DIR/ul.cfg, for OPL on FAT32 USB drives. The parts are read back
+and compared with the source before ul.cfg is updated.
+
+GAME is a game ID, a file name or part of one; use --all for every game.`,
+	Example: `  ps2smb games ul export /media/usb SLUS_203.12`,
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runULExport(args[0], args[1:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	gamesCmd.AddCommand(gamesULCmd)
+	gamesULCmd.AddCommand(gamesULListCmd, gamesULImportCmd, gamesULExportCmd)
+	addOutputFlags(gamesULListCmd)
+	gamesULImportCmd.Flags().BoolVar(&ulAll, "all", false, "Import every game in ul.cfg")
+	gamesULExportCmd.Flags().BoolVar(&ulAll, "all", false, "Export every game in the library")
+}
+
+func runULList(dir string) error {
+	if err := outputOpts.Validate(); err != nil {
+		return err
+	}
+
+	games, err := library.ReadULConfig(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", library.ULConfigName, err)
+	}
+
+	if outputOpts.Structured() {
+		if games == nil {
+			games = []library.ULGame{}
+		}
+		return render(games)
+	}
+
+	if len(games) == 0 {
+		fmt.Printf("No games in %s/%s\n", dir, library.ULConfigName)
+		return nil
+	}
+
+	fmt.Printf("Games in %s/%s (%d)\n", dir, library.ULConfigName, len(games))
+	fmt.Println()
+	fmt.Printf("%-11s  %-32s  %-5s  %5s  %9s\n", "ID", "Name", "Media", "Parts", "Size")
+	for _, g := range games {
+		fmt.Printf("%-11s  %-32s  %-5s  %5d  %9s\n", g.ID, g.Name, g.Media, g.Parts, humanSize(g.Size))
+		if len(g.Missing) > 0 {
+			fmt.Printf("  ✗ missing: %s\n", strings.Join(g.Missing, ", "))
+		}
+	}
+	return nil
+}
+
+func runULImport(dir string, args []string) error {
+	if len(args) == 0 && !ulAll {
+		return fmt.Errorf("name the games to import or pass --all")
+	}
+
+	ulGames, err := library.ReadULConfig(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", library.ULConfigName, err)
+	}
+
+	selected := ulGames
+	if !ulAll {
+		selected = nil
+		for _, arg := range args {
+			g, err := findULGame(ulGames, arg)
+			if err != nil {
+				return err
+			}
+			selected = append(selected, g)
+		}
+	}
+
+	cfg, cat, err := loadCatalog()
+	if err != nil {
+		return err
+	}
+	opts := library.AddOptions{UID: -1, GID: -1}
+	if uid, gid, ok := shareOwner(cfg); ok {
+		opts.UID, opts.GID = uid, gid
+	}
+
+	added, failed := 0, 0
+	for _, ul := range selected {
+		fmt.Printf("%s (%s)\n", ul.Name, ul.ID)
+
+		progress := newProgressLine()
+		opts.Progress = progress.update
+		res, err := library.AddUL(cat, dir, ul, opts)
+		progress.finish()
+
+		if err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			failed++
+			continue
+		}
+		if res.Resumed > 0 {
+			fmt.Printf("  Resumed after %s\n", humanSize(res.Resumed))
+		}
+		fmt.Printf("  ✓ %s/%s (joined %d part(s))\n", res.Game.Media, res.Game.File, ul.Parts)
+		fmt.Printf("    SHA-1 %s verified\n", res.SHA1)
+		added++
+	}
+
+	fmt.Println()
+	fmt.Printf("Imported: %d  Failed: %d\n", added, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d game(s) could not be imported", failed)
+	}
+	return nil
+}
+
+// findULGame matches a game ID or part of a ul.cfg name
+func findULGame(games []library.ULGame, arg string) (library.ULGame, error) {
+	var matches []library.ULGame
+	for _, g := range games {
+		if strings.EqualFold(g.ID, arg) {
+			return g, nil
+		}
+		if strings.Contains(strings.ToLower(g.Name), strings.ToLower(arg)) {
+			matches = append(matches, g)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return library.ULGame{}, fmt.Errorf("no game in %s matches %q", library.ULConfigName, arg)
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = fmt.Sprintf("%s (%s)", m.Name, m.ID)
+	}
+	return library.ULGame{}, fmt.Errorf("%q matches several games: %s", arg, strings.Join(names, ", "))
+}
+
+func runULExport(dir string, args []string) error {
+	if len(args) == 0 && !ulAll {
+		return fmt.Errorf("name the games to export or pass --all")
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	_, cat, err := loadCatalog()
+	if err != nil {
+		return err
+	}
+
+	var candidates []library.Game
+	for _, g := range cat.Games {
+		if g.Error == "" {
+			candidates = append(candidates, g)
+		}
+	}
+	games := candidates
+	if !ulAll {
+		if games, err = selectGames(candidates, args); err != nil {
+			return err
+		}
+	}
+
+	exported, failed := 0, 0
+	for _, g := range games {
+		fmt.Printf("%s\n", g.RelPath())
+
+		progress := newProgressLine()
+		ul, sum, err := library.ExportUL(dir, g, progress.update)
+		progress.finish()
+
+		if err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Printf("  ✓ %q as %s.* (%d part(s), %s)\n", ul.Name, strings.TrimSuffix(ul.PartName(0), ".00"), ul.Parts, ul.Media)
+		fmt.Printf("    SHA-1 %s verified\n", sum)
+		exported++
+	}
+
+	fmt.Println()
+	fmt.Printf("Exported: %d  Failed: %d\n", exported, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d game(s) could not be exported", failed)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/library"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyDAT     string
+	verifyJobs    int
+	verifyNoCache bool
+)
+
+var gamesVerifyCmd = &cobra.Command{
+	Use:   "verify --dat FILE [GAME...]",
+	Short: "Check images against a Redump or No-Intro DAT file",
+	Long: `Hashes every image (CRC32, MD5 and SHA-1) and looks it up in a local Logiqx
+XML DAT file such as the ones Redump publishes:
+
+  verified  the image matches a DAT entry exactly
+  unknown   the game is not in the DAT
+  bad       the DAT knows the game (by serial or title) but the image
+            does not match it
+
+ZSO images are hashed decompressed. Hashes are cached by path, size and
+modification time, so only new or changed images are read again.`,
+	Example: `  ps2smb games verify --dat "Sony - PlayStation 2.dat"
+  ps2smb games verify --dat ps2.dat SLUS_203.12`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runGamesVerify(args); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	gamesCmd.AddCommand(gamesVerifyCmd)
+	gamesVerifyCmd.Flags().StringVar(&verifyDAT, "dat", "", "Logiqx XML DAT file to verify against")
+	gamesVerifyCmd.Flags().IntVarP(&verifyJobs, "jobs", "j", min(runtime.NumCPU(), 4), "Images to hash in parallel")
+	gamesVerifyCmd.Flags().BoolVar(&verifyNoCache, "no-cache", false, "Hash every image again")
+	gamesVerifyCmd.MarkFlagRequired("dat")
+	addOutputFlags(gamesVerifyCmd)
+}
+
+// hashCachePath is where checksums are kept between runs
+func hashCachePath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "hashes.json"), nil
+}
+
+func runGamesVerify(args []string) error {
+	if err := outputOpts.Validate(); err != nil {
+		return err
+	}
+
+	dat, err := library.ReadDAT(verifyDAT)
+	if err != nil {
+		return err
+	}
+
+	_, cat, err := loadCatalog()
+	if err != nil {
+		return err
+	}
+	var games []library.Game
+	for _, g := range cat.Games {
+		if g.Error == "" {
+			games = append(games, g)
+		}
+	}
+	if len(args) > 0 {
+		if games, err = selectGames(games, args); err != nil {
+			return err
+		}
+	}
+
+	opts := library.HashOptions{Jobs: verifyJobs}
+	cachePath, err := hashCachePath()
+	if err == nil && !verifyNoCache {
+		opts.Cache = library.LoadHashCache(cachePath)
+	}
+
+	var progress *progressLine
+	if !outputOpts.Structured() {
+		fmt.Printf("Verifying %d image(s) against %s", len(games), dat.Name)
+		if dat.Version != "" {
+			fmt.Printf(" (%s)", dat.Version)
+		}
+		fmt.Println()
+		progress = newProgressLine()
+		opts.Progress = func(done, total int64) { progress.update("hash", done, total) }
+	}
+
+	results := library.Verify(games, dat, opts)
+	if progress != nil {
+		progress.finish()
+	}
+
+	if opts.Cache != nil {
+		if err := opts.Cache.Save(); err != nil && !outputOpts.Structured() {
+			fmt.Printf("Warning: failed to save hash cache: %v\n", err)
+		}
+	}
+
+	// Structured output carries each status, so it is not turned into an
+	// error that would print after it
+	if outputOpts.Structured() {
+		if results == nil {
+			results = []library.Verification{}
+		}
+		return render(results)
+	}
+
+	counts := make(map[library.VerifyStatus]int)
+	cached := 0
+	fmt.Println()
+	for _, r := range results {
+		counts[r.Status]++
+		if r.Cached {
+			cached++
+		}
+
+		switch r.Status {
+		case library.StatusVerified:
+			fmt.Printf("✓ %s\n    %s\n", r.File, r.Match)
+		case library.StatusUnknown:
+			fmt.Printf("? %s\n    not in the DAT", r.File)
+			if r.Note != "" {
+				fmt.Printf("; closest: %s (%s)", r.Match, r.Note)
+			}
+			fmt.Println()
+		case library.StatusBad:
+			fmt.Printf("✗ %s\n    bad dump of %s: %s\n", r.File, r.Match, r.Mismatch())
+		default:
+			fmt.Printf("✗ %s\n    %s\n", r.File, r.Error)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("Verified: %d  Unknown: %d  Bad: %d", counts[library.StatusVerified], counts[library.StatusUnknown], counts[library.StatusBad])
+	if n := counts[library.StatusError]; n > 0 {
+		fmt.Printf("  Unreadable: %d", n)
+	}
+	if cached > 0 {
+		fmt.Printf("  (%d from cache)", cached)
+	}
+	fmt.Println()
+
+	if n := counts[library.StatusBad] + counts[library.StatusError]; n > 0 {
+		return fmt.Errorf("%d image(s) failed verification", n)
+	}
+	return nil
+}
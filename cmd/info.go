@@ -2,19 +2,24 @@ package cmd
 
 import (
 	"fmt"
+	"net"
 	"os"
-	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/library"
+	"github.com/matheusc457/ps2smb/internal/netbios"
 	"github.com/matheusc457/ps2smb/internal/network"
 	"github.com/matheusc457/ps2smb/internal/samba"
+	"github.com/matheusc457/ps2smb/internal/titledb"
 	"github.com/spf13/cobra"
 )
 
 var (
-	useNetBIOS       bool
-	interfaceName    string
+	useNetBIOS    bool
+	interfaceName string
+	suggestIP     bool
 )
 
 var infoCmd = &cobra.Command{
@@ -33,38 +38,107 @@ func init() {
 	rootCmd.AddCommand(infoCmd)
 	infoCmd.Flags().BoolVarP(&useNetBIOS, "netbios", "n", false, "Use NetBIOS name instead of IP address")
 	infoCmd.Flags().StringVarP(&interfaceName, "interface", "i", "", "Specify network interface (e.g., eth0, enp3s0)")
+	infoCmd.Flags().BoolVar(&suggestIP, "suggest-ip", false, "Probe the subnet for an unused static address for the PS2 (best-effort)")
+	addOutputFlags(infoCmd)
 }
 
-func getHostname() (string, error) {
-	// Method 1: Try /etc/hostname (works on most Linux)
-	data, err := os.ReadFile("/etc/hostname")
-	if err == nil && len(data) > 0 {
-		return strings.ToUpper(strings.TrimSpace(string(data))), nil
-	}
-	
-	// Method 2: Try hostnamectl command (systemd-based)
-	cmd := exec.Command("hostnamectl", "hostname")
-	output, err := cmd.Output()
-	if err == nil && len(output) > 0 {
-		return strings.ToUpper(strings.TrimSpace(string(output))), nil
+// getNetBIOSName returns the name Samba announces: 'netbios name' from
+// smb.conf or the first label of the hostname. The name is returned along
+// with an error when OPL could not use it
+func getNetBIOSName() (string, error) {
+	id, err := netbios.ServerIdentity()
+	if err != nil {
+		return "", err
 	}
-	
-	// Method 3: Try hostname command (traditional)
-	cmd = exec.Command("hostname")
-	output, err = cmd.Output()
-	if err == nil && len(output) > 0 {
-		return strings.ToUpper(strings.TrimSpace(string(output))), nil
+	if err := netbios.ValidateName(id.Name); err != nil {
+		return id.Name, err
 	}
-	
-	// Method 4: Fallback to os.Hostname() (Go builtin)
-	name, err := os.Hostname()
+	return id.Name, nil
+}
+
+// Limits for --suggest-ip so a busy /16 does not take minutes to scan
+const (
+	probeTimeout = 500 * time.Millisecond
+	probeLimit   = 64
+)
+
+// infoReport is the structured form of 'ps2smb info'
+type infoReport struct {
+	IP          string              `json:"ip"`
+	Interface   string              `json:"interface"`
+	Reasons     []string            `json:"interface_reasons,omitempty"`
+	NetBIOSName string              `json:"netbios_name,omitempty"`
+	Share       string              `json:"share"`
+	SMBPath     string              `json:"smb_path"`
+	GamesPath   string              `json:"games_path"`
+	Auth        authInfo            `json:"auth"`
+	Service     serviceInfo         `json:"service"`
+	Network     *networkInfo        `json:"network,omitempty"`
+	Library     *libraryInfo        `json:"library,omitempty"`
+	Interfaces  []network.Interface `json:"interfaces"`
+}
+
+type authInfo struct {
+	Mode string `json:"mode"`
+	User string `json:"user,omitempty"`
+}
+
+type networkInfo struct {
+	Subnet         string `json:"subnet"`
+	Mask           string `json:"mask"`
+	Broadcast      string `json:"broadcast"`
+	Gateway        string `json:"gateway,omitempty"`
+	SuggestedPS2IP string `json:"suggested_ps2_ip,omitempty"`
+	Probed         bool   `json:"suggestion_probed"`
+}
+
+// libraryInfo summarizes the games directory
+type libraryInfo struct {
+	Games      int `json:"games"`
+	DVD        int `json:"dvd"`
+	CD         int `json:"cd"`
+	Unreadable int `json:"unreadable"`
+	Titles     int `json:"titles_known"`
+	DBSize     int `json:"title_database_entries"`
+}
+
+// summarizeLibrary scans the library for 'info'. It returns nil when the
+// games directory cannot be read
+func summarizeLibrary() *libraryInfo {
+	_, cat, err := loadCatalog()
 	if err != nil {
-		return "", err
+		return nil
+	}
+	info := &libraryInfo{Games: len(cat.Games)}
+	for _, g := range cat.Games {
+		switch {
+		case g.Error != "":
+			info.Unreadable++
+		case g.Media == library.MediaCD:
+			info.CD++
+		default:
+			info.DVD++
+		}
+		if g.DBTitle != "" {
+			info.Titles++
+		}
+	}
+	if db, err := titledb.Load(); err == nil {
+		info.DBSize = db.Len()
 	}
-	return strings.ToUpper(name), nil
+	return info
+}
+
+type serviceInfo struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
 }
 
 func runInfo() error {
+	if err := outputOpts.Validate(); err != nil {
+		return err
+	}
+
 	// Check if ps2smb is configured
 	if !config.Exists() {
 		return fmt.Errorf("ps2smb is not configured yet.\nPlease run 'sudo ps2smb init' first to set up the server.\n\nNote: This command (info) should also be run with sudo to check server status")
@@ -78,6 +152,8 @@ func runInfo() error {
 
 	// Get local IP
 	var ip string
+	selectedIface := interfaceName
+	var selectReasons []string
 	
 	if interfaceName != "" {
 		// Use specified interface
@@ -87,16 +163,30 @@ func runInfo() error {
 			return fmt.Errorf("failed to get IP from interface %s: %v", interfaceName, ipErr)
 		}
 	} else {
-		// Auto-detect IP
-		var ipErr error
-		ip, ipErr = network.GetLocalIP()
-		if ipErr != nil {
-			return fmt.Errorf("failed to detect IP address: %v", ipErr)
+		// Auto-detect the interface facing the PS2
+		best, _, selErr := network.SelectPrimary()
+		if selErr != nil {
+			return fmt.Errorf("failed to detect IP address: %v", selErr)
+		}
+		ip = best.Address.IP.String()
+		selectedIface = best.Interface.Name
+		selectReasons = best.Reasons
+	}
+
+	// Work out the subnet so static-IP advice matches the real network
+	subnet, subnetErr := network.SubnetFor(selectedIface, net.ParseIP(ip))
+	var suggestedIP net.IP
+	var probeErr error
+	if subnetErr == nil {
+		if suggestIP {
+			suggestedIP, _, probeErr = network.FindFreeIP(subnet, probeTimeout, probeLimit)
+		} else {
+			suggestedIP, _ = subnet.SuggestPS2IP()
 		}
 	}
 
-	// Get hostname for NetBIOS
-	hostname, hostnameErr := getHostname()
+	// Get the NetBIOS name OPL would look up
+	hostname, hostnameErr := getNetBIOSName()
 
 	// Check Samba status (may require sudo)
 	sambaRunning := false
@@ -114,31 +204,99 @@ func runInfo() error {
 	
 	statusSymbol := "?"
 	statusText := "Unknown (run with sudo to check)"
+	serviceState := "unknown"
 	
 	if canCheckStatus || samba.IsRoot() {
 		if sambaRunning {
 			statusSymbol = "✓"
 			statusText = "Running"
+			serviceState = "running"
 		} else {
 			statusSymbol = "✗"
 			statusText = "Not Running"
+			serviceState = "stopped"
 		}
 	}
 
 	// Format SMB path
 	smbPath := network.FormatSMBPath(ip, cfg.ShareName)
 
+	lib := summarizeLibrary()
+
+	if outputOpts.Structured() {
+		report := infoReport{
+			IP:        ip,
+			Interface: selectedIface,
+			Reasons:   selectReasons,
+			Share:     cfg.ShareName,
+			SMBPath:   smbPath,
+			GamesPath: cfg.GamesPath,
+			Auth:      authInfo{Mode: "guest"},
+			Service: serviceInfo{
+				Name:  samba.GetSambaServiceName(),
+				State: serviceState,
+			},
+			Library: lib,
+		}
+		if hostnameErr == nil {
+			report.NetBIOSName = hostname
+		}
+		if !cfg.UseGuest {
+			report.Auth = authInfo{Mode: "user", User: cfg.SambaUser}
+		}
+		if subnetErr == nil {
+			report.Network = &networkInfo{
+				Subnet:    subnet.String(),
+				Mask:      subnet.MaskString(),
+				Broadcast: subnet.Broadcast.String(),
+			}
+			if subnet.Gateway != nil {
+				report.Network.Gateway = subnet.Gateway.String()
+			}
+			if suggestedIP != nil {
+				report.Network.SuggestedPS2IP = suggestedIP.String()
+				report.Network.Probed = suggestIP
+			}
+		}
+		report.Interfaces, err = network.ListInterfaces()
+		if err != nil {
+			return fmt.Errorf("failed to list interfaces: %v", err)
+		}
+		return render(report)
+	}
+
 	// Display information
 	fmt.Println("PS2SMB Connection Information")
 	fmt.Println("=============================")
 	fmt.Println()
 	fmt.Printf("Server Status: %s %s\n", statusSymbol, statusText)
 	fmt.Printf("IP Address: %s\n", ip)
+	if len(selectReasons) > 0 {
+		fmt.Printf("Interface: %s (auto-selected: %s)\n", selectedIface, strings.Join(selectReasons, ", "))
+	} else {
+		fmt.Printf("Interface: %s\n", selectedIface)
+	}
 	if hostname != "" {
-		fmt.Printf("NetBIOS Name: %s\n", hostname)
+		if hostnameErr != nil {
+			fmt.Printf("NetBIOS Name: %s (unusable: %v)\n", hostname, hostnameErr)
+		} else {
+			fmt.Printf("NetBIOS Name: %s\n", hostname)
+		}
 	}
 	fmt.Printf("Share Name: %s\n", cfg.ShareName)
 	fmt.Printf("Games Path: %s\n", cfg.GamesPath)
+	if lib != nil {
+		fmt.Printf("Library: %d game(s) (%d DVD, %d CD)", lib.Games, lib.DVD, lib.CD)
+		if lib.Unreadable > 0 {
+			fmt.Printf(", %d unreadable", lib.Unreadable)
+		}
+		fmt.Println()
+		if lib.DBSize > 0 {
+			fmt.Printf("  Titles: %d of %d known to the title database\n", lib.Titles, lib.Games)
+		} else {
+			fmt.Println("  Titles: from file names (import a title list with 'ps2smb db import')")
+		}
+	}
 	fmt.Println()
 
 	// Authentication info
@@ -165,24 +323,43 @@ func runInfo() error {
 	fmt.Println("   - IP address type: DHCP (recommended)")
 	fmt.Println()
 	fmt.Println("   OR if using Static IP:")
-	fmt.Printf("     - IP address: 192.168.1.10 (must be in same network as %s)\n", ip)
-	fmt.Println("       * Choose an available IP in the same range")
-	fmt.Printf("       * Example: If PC is %s, PS2 could be 192.168.1.10, 192.168.1.20, etc\n", ip)
-	fmt.Println("     - Mask: 255.255.255.0")
-	fmt.Printf("     - Gateway: %s (your PC's IP for direct connection, or router IP)\n", ip)
+	if subnetErr != nil || suggestedIP == nil {
+		if probeErr != nil {
+			fmt.Printf("     - No unused address found: %v\n", probeErr)
+		} else {
+			fmt.Printf("     - Could not determine the subnet of %s; use an unused address in the same network\n", ip)
+		}
+	} else {
+		if suggestIP {
+			fmt.Printf("     - IP address: %s (no device answered ARP, ICMP or TCP probes;\n", network.FormatOPL(suggestedIP))
+			fmt.Println("       best-effort only, devices that are off may still use it)")
+		} else {
+			fmt.Printf("     - IP address: %s (suggested; must not be used by another device)\n", network.FormatOPL(suggestedIP))
+			fmt.Println("       * Run 'ps2smb info --suggest-ip' to probe for conflicts")
+		}
+		fmt.Printf("       * Any unused address in %s works\n", subnet)
+		fmt.Printf("     - Mask: %s\n", network.FormatOPL(net.IP(subnet.Mask)))
+		if subnet.Gateway != nil {
+			fmt.Printf("     - Gateway: %s (your router)\n", network.FormatOPL(subnet.Gateway))
+		} else {
+			fmt.Printf("     - Gateway: %s (this PC, direct connection)\n", network.FormatOPL(net.ParseIP(ip)))
+		}
+	}
 	fmt.Println()
 	
 	fmt.Println("3. SMB Server Settings:")
 	
 	if useNetBIOS && hostname != "" && hostnameErr == nil {
 		fmt.Println("   - Address type: NetBIOS")
-		fmt.Printf("   - Address: %s (hostname in UPPERCASE)\n", hostname)
+		fmt.Printf("   - Address: %s\n", hostname)
+		fmt.Println("     * Requires nmbd; verify with 'ps2smb netbios status'")
 	} else {
 		if useNetBIOS && (hostname == "" || hostnameErr != nil) {
-			fmt.Println("   WARNING: Could not get hostname, using IP instead")
+			fmt.Printf("   WARNING: NetBIOS name unusable (%v), using IP instead\n", hostnameErr)
+			fmt.Println("   Fix with: sudo ps2smb netbios set --name <NAME>")
 		}
 		fmt.Println("   - Address type: IP")
-		fmt.Printf("   - Address: %s\n", ip)
+		fmt.Printf("   - Address: %s\n", network.FormatOPL(net.ParseIP(ip)))
 	}
 	
 	fmt.Printf("   - Share: %s\n", cfg.ShareName)
@@ -200,6 +377,7 @@ func runInfo() error {
 	fmt.Println("4. Advanced Settings (if needed):")
 	fmt.Println("   - For direct crossover cable connection:")
 	fmt.Println("     - Ethernet operation mode: 100Mbit half-duplex")
+	fmt.Println("     - Run 'sudo ps2smb direct --interface <name>' to serve DHCP on that link")
 	fmt.Println()
 	
 	fmt.Println("5. Save settings and select 'Reconnect'")
@@ -212,16 +390,25 @@ func runInfo() error {
 
 	if !sambaRunning {
 		fmt.Println("WARNING: Samba service is not running!")
-		fmt.Println("Start it with: sudo systemctl start smb")
+		fmt.Printf("Start it with: sudo systemctl start %s\n", samba.GetSambaServiceName())
 		fmt.Println()
 	}
 
 	// Show all IPs if multiple interfaces
-	allIPs, ipsErr := network.GetAllLocalIPs()
-	if ipsErr == nil && len(allIPs) > 1 {
+	var usable []network.Interface
+	if interfaces, err := network.ListInterfaces(); err == nil {
+		for _, iface := range interfaces {
+			if iface.Up() && len(iface.IPv4()) > 0 {
+				usable = append(usable, iface)
+			}
+		}
+	}
+	if len(usable) > 1 {
 		fmt.Println("Available network interfaces:")
-		for _, ip := range allIPs {
-			fmt.Printf("  - %s\n", ip)
+		for _, iface := range usable {
+			for _, addr := range iface.IPv4() {
+				fmt.Printf("  - %s (%s, %s)\n", addr.IP, iface.Name, iface.Kind)
+			}
 		}
 		fmt.Println()
 		fmt.Println("Tip: Use the interface connected to your PS2")
@@ -3,10 +3,12 @@ package cmd
 import (
 	"bufio"
 	"fmt"
+	"net"
 	"os"
 	"strings"
 
 	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/network"
 	"github.com/matheusc457/ps2smb/internal/samba"
 	"github.com/spf13/cobra"
 )
@@ -23,8 +25,15 @@ var initCmd = &cobra.Command{
 	},
 }
 
+var (
+	initInterface  string
+	initHostsAllow string
+)
+
 func init() {
 	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().StringVarP(&initInterface, "interface", "i", "", "Only serve Samba on this interface (plus loopback)")
+	initCmd.Flags().StringVar(&initHostsAllow, "hosts-allow", "", "Restrict the share to a subnet, PS2 IP or PS2 MAC (default: the interface's subnet)")
 }
 
 func runInit() error {
@@ -33,12 +42,16 @@ func runInit() error {
 	fmt.Println()
 
 	// Check if already configured
+	prevBind := ""
 	if config.Exists() {
 		fmt.Println("Warning: ps2smb is already configured.")
 		if !askYesNo("Do you want to reconfigure?") {
 			fmt.Println("Initialization cancelled.")
 			return nil
 		}
+		if prev, err := config.Load(); err == nil {
+			prevBind = prev.BindInterface
+		}
 	}
 
 	// Check root privileges
@@ -105,6 +118,38 @@ func runInit() error {
 		return fmt.Errorf("failed to add PS2 share: %v", err)
 	}
 
+	// SMB1 and NTLMv1/guest mapping are required by OPL
+	fmt.Println("Applying global Samba settings for OPL...")
+	if err := samba.ApplyGlobalSettings(useGuest); err != nil {
+		return fmt.Errorf("failed to apply global settings: %v", err)
+	}
+
+	// Restrict Samba to the PS2-facing interface if requested
+	hostsAllow := ""
+	if initInterface != "" || initHostsAllow != "" {
+		hostsAllow, err = resolveHostsAllow(initInterface, initHostsAllow)
+		if err != nil {
+			return err
+		}
+	}
+	if initInterface != "" {
+		fmt.Printf("Binding Samba to lo and %s...\n", initInterface)
+		if err := samba.BindToInterface(initInterface); err != nil {
+			return fmt.Errorf("failed to bind Samba to %s: %v", initInterface, err)
+		}
+	} else if prevBind != "" {
+		// Only undo a binding an earlier 'init --interface' made
+		if err := samba.UnbindInterface(prevBind); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	if hostsAllow != "" {
+		fmt.Printf("Allowing only %s to connect...\n", hostsAllow)
+	}
+	if err := samba.SetHostsAllow(hostsAllow); err != nil {
+		return fmt.Errorf("failed to set hosts allow: %v", err)
+	}
+
 	// Create Samba user if needed
 	if !useGuest {
 		fmt.Println("\nCreating Samba user 'ps2user'...")
@@ -132,6 +177,8 @@ func runInit() error {
 		ShareName:     samba.ShareName,
 		UseGuest:      useGuest,
 		SambaUser:     sambaUser,
+		BindInterface: initInterface,
+		HostsAllow:    hostsAllow,
 		ConfigVersion: "1.0",
 	}
 
@@ -155,6 +202,57 @@ func runInit() error {
 	return nil
 }
 
+// resolveHostsAllow turns --hosts-allow into a Samba hosts allow value.
+// A MAC address is resolved through the ARP table; an empty value means the
+// subnet of the bound interface. Loopback and the server's own address are
+// always allowed so local checks work.
+func resolveHostsAllow(iface, value string) (string, error) {
+	if value == "" {
+		if iface == "" {
+			return "", nil
+		}
+		info, err := network.GetInterface(iface)
+		if err != nil {
+			return "", fmt.Errorf("interface %s not found: %v", iface, err)
+		}
+		addrs := info.IPv4()
+		if len(addrs) == 0 {
+			fmt.Printf("Warning: %s has no IPv4 address; the share will not be restricted by host\n", iface)
+			return "", nil
+		}
+		return "127.0.0.1 " + network.NewSubnet(addrs[0]).String(), nil
+	}
+
+	if mac, err := net.ParseMAC(value); err == nil {
+		ip, err := network.LookupMAC(mac, iface)
+		if err != nil {
+			return "", err
+		}
+		return selfHosts(iface) + " " + ip.String(), nil
+	}
+
+	if net.ParseIP(value) == nil {
+		if _, _, err := net.ParseCIDR(value); err != nil {
+			return "", fmt.Errorf("invalid --hosts-allow %q: expected a subnet, IP or MAC address", value)
+		}
+	}
+	return selfHosts(iface) + " " + value, nil
+}
+
+// selfHosts returns the addresses ps2smb's own checks connect from: 'status
+// --deep' and 'bench' connect to the address advertised to the PS2, which
+// the kernel also uses as their source address
+func selfHosts(iface string) string {
+	ip, err := network.GetLocalIP()
+	if iface != "" {
+		ip, err = network.GetIPFromInterface(iface)
+	}
+	if err != nil {
+		return "127.0.0.1"
+	}
+	return "127.0.0.1 " + ip
+}
+
 func askYesNo(question string) bool {
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Printf("%s (y/N): ", question)
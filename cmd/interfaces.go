@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/matheusc457/ps2smb/internal/network"
 	"github.com/spf13/cobra"
@@ -22,16 +23,25 @@ var interfacesCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(interfacesCmd)
+	addOutputFlags(interfacesCmd)
 }
 
 func runInterfaces() error {
+	if err := outputOpts.Validate(); err != nil {
+		return err
+	}
+
 	interfaces, err := network.ListInterfaces()
 	if err != nil {
 		return fmt.Errorf("failed to list interfaces: %v", err)
 	}
 
+	if outputOpts.Structured() {
+		return render(interfaces)
+	}
+
 	if len(interfaces) == 0 {
-		fmt.Println("No active network interfaces found")
+		fmt.Println("No network interfaces found")
 		return nil
 	}
 
@@ -39,9 +49,24 @@ func runInterfaces() error {
 	fmt.Println("=============================")
 	fmt.Println()
 
-	for name, ip := range interfaces {
-		fmt.Printf("  %s\n", name)
-		fmt.Printf("    IP Address: %s\n", ip)
+	for _, iface := range interfaces {
+		fmt.Printf("  %s (%s)\n", iface.Name, iface.Kind)
+		if iface.MAC != "" {
+			fmt.Printf("    MAC: %s\n", iface.MAC)
+		}
+		fmt.Printf("    State: %s\n", linkSummary(iface))
+		for _, addr := range iface.Addresses {
+			if addr.IsIPv4() {
+				fmt.Printf("    IPv4: %s\n", addr)
+			} else {
+				fmt.Printf("    IPv6: %s\n", addr)
+			}
+		}
+		fmt.Println()
+	}
+
+	if best, _, err := network.SelectPrimary(); err == nil {
+		fmt.Printf("Auto-selected for PS2: %s (%s)\n", best.Interface.Name, best.Explain())
 		fmt.Println()
 	}
 
@@ -53,3 +78,25 @@ func runInterfaces() error {
 
 	return nil
 }
+
+// linkSummary describes operstate, carrier, speed and duplex on one line
+func linkSummary(iface network.Interface) string {
+	parts := []string{iface.OperState}
+	if iface.OperState == "" {
+		parts[0] = "unknown"
+	}
+	if iface.Carrier {
+		parts = append(parts, "carrier")
+	} else {
+		parts = append(parts, "no carrier")
+	}
+	if iface.SpeedMbps > 0 {
+		speed := fmt.Sprintf("%d Mb/s", iface.SpeedMbps)
+		if iface.Duplex != "" {
+			speed += " " + iface.Duplex + "-duplex"
+		}
+		parts = append(parts, speed)
+	}
+	parts = append(parts, fmt.Sprintf("MTU %d", iface.MTU))
+	return strings.Join(parts, ", ")
+}
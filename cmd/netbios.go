@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/netbios"
+	"github.com/matheusc457/ps2smb/internal/network"
+	"github.com/matheusc457/ps2smb/internal/samba"
+	"github.com/spf13/cobra"
+)
+
+var (
+	netbiosName      string
+	netbiosWorkgroup string
+	netbiosAddress   string
+)
+
+var netbiosCmd = &cobra.Command{
+	Use:   "netbios",
+	Short: "Manage the NetBIOS name OPL can use instead of the IP",
+	Long: `OPL can reach the server by NetBIOS name instead of IP address. This needs a
+valid name (at most 15 characters, no dots, spaces or \/:*?"<>|), a running
+nmbd and an answer to broadcast name queries on UDP 137.`,
+	Example: `  ps2smb netbios status
+  sudo ps2smb netbios set --name PS2SERVER --workgroup WORKGROUP
+  sudo ps2smb netbios enable`,
+}
+
+var netbiosStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Validate the name, check nmbd and query the name on the LAN",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runNetBIOSStatus(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var netbiosSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set 'netbios name' and/or 'workgroup' in [global]",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runNetBIOSSet(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var netbiosEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable and start the NetBIOS name service (nmbd)",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runNetBIOSEnable(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(netbiosCmd)
+	netbiosCmd.AddCommand(netbiosStatusCmd, netbiosSetCmd, netbiosEnableCmd)
+	netbiosStatusCmd.Flags().StringVarP(&netbiosAddress, "address", "a", "", "Query this host directly instead of broadcasting on the PS2 subnet")
+	addOutputFlags(netbiosStatusCmd)
+	netbiosSetCmd.Flags().StringVarP(&netbiosName, "name", "n", "", "NetBIOS name of the server")
+	netbiosSetCmd.Flags().StringVarP(&netbiosWorkgroup, "workgroup", "w", "", "Workgroup to join")
+}
+
+// netbiosReport is the structured output of 'ps2smb netbios status'
+type netbiosReport struct {
+	Name       string         `json:"name"`
+	Configured bool           `json:"configured"`
+	Workgroup  string         `json:"workgroup"`
+	Valid      bool           `json:"valid"`
+	Problem    string         `json:"problem,omitempty"`
+	Service    nmbdInfo       `json:"service"`
+	Query      *nameQueryInfo `json:"query,omitempty"`
+}
+
+type nmbdInfo struct {
+	Name      string `json:"name"`
+	Installed bool   `json:"installed"`
+	Running   bool   `json:"running"`
+	Enabled   bool   `json:"enabled"`
+}
+
+type nameQueryInfo struct {
+	Target    string   `json:"target"`
+	Broadcast bool     `json:"broadcast"`
+	Addresses []string `json:"addresses,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+func runNetBIOSStatus() error {
+	if err := outputOpts.Validate(); err != nil {
+		return err
+	}
+
+	id, err := netbios.ServerIdentity()
+	if err != nil {
+		return err
+	}
+
+	report := netbiosReport{
+		Name:       id.Name,
+		Configured: id.Configured,
+		Workgroup:  id.Workgroup,
+		Valid:      true,
+		Service: nmbdInfo{
+			Name:      samba.GetNmbdServiceName(),
+			Installed: samba.IsNmbdInstalled(),
+			Running:   samba.IsNmbdRunning(),
+			Enabled:   samba.IsNmbdEnabled(),
+		},
+	}
+	if err := netbios.ValidateName(id.Name); err != nil {
+		report.Valid = false
+		report.Problem = err.Error()
+	}
+
+	if report.Valid {
+		report.Query = queryServerName(id.Name)
+	}
+
+	if outputOpts.Structured() {
+		return render(report)
+	}
+
+	fmt.Println("NetBIOS Status")
+	fmt.Println("==============")
+	fmt.Println()
+
+	source := "derived from hostname"
+	if id.Configured {
+		source = "set in smb.conf"
+	}
+	fmt.Printf("Name: %s (%s) ", id.Name, source)
+	printStatus(report.Valid)
+	if !report.Valid {
+		fmt.Printf("  %s\n", report.Problem)
+		if fixed := netbios.Sanitize(id.Name); netbios.ValidateName(fixed) == nil {
+			fmt.Printf("  Fix with: sudo ps2smb netbios set --name %s\n", fixed)
+		}
+	}
+	fmt.Printf("Workgroup: %s\n", id.Workgroup)
+	fmt.Println()
+
+	svc := report.Service
+	fmt.Printf("%s installed... ", svc.Name)
+	printStatus(svc.Installed)
+	fmt.Printf("%s running... ", svc.Name)
+	printStatus(svc.Running)
+	fmt.Printf("%s enabled on boot... ", svc.Name)
+	printStatus(svc.Enabled)
+	if svc.Installed && (!svc.Running || !svc.Enabled) {
+		fmt.Println("  Fix with: sudo ps2smb netbios enable")
+	}
+
+	if q := report.Query; q != nil {
+		fmt.Println()
+		fmt.Printf("Name query for %s<20> to %s... ", id.Name, q.Target)
+		printStatus(q.Error == "")
+		if q.Error != "" {
+			fmt.Printf("  %s\n", q.Error)
+		} else {
+			fmt.Printf("  Resolves to %s\n", strings.Join(q.Addresses, ", "))
+		}
+	}
+
+	fmt.Println()
+	if report.Valid && svc.Running && report.Query != nil && report.Query.Error == "" {
+		fmt.Printf("OPL can use Address type NetBIOS with Address %s\n", id.Name)
+	} else {
+		fmt.Println("Use the IP address in OPL until the problems above are fixed.")
+	}
+	return nil
+}
+
+// queryServerName resolves the name the way OPL does: a broadcast on the
+// PS2 subnet, or a unicast query to --address
+func queryServerName(name string) *nameQueryInfo {
+	info := &nameQueryInfo{Target: netbiosAddress}
+
+	var target net.IP
+	if netbiosAddress != "" {
+		target = net.ParseIP(netbiosAddress)
+		if target == nil || target.To4() == nil {
+			info.Error = fmt.Sprintf("invalid IPv4 address %q", netbiosAddress)
+			return info
+		}
+	} else {
+		iface := ""
+		if cfg, err := config.Load(); err == nil {
+			iface = cfg.BindInterface
+		}
+		subnet, err := network.PS2Subnet(iface)
+		if err != nil {
+			info.Error = fmt.Sprintf("failed to determine the PS2 subnet: %v", err)
+			return info
+		}
+		target = subnet.Broadcast
+		info.Target = target.String()
+		info.Broadcast = true
+	}
+
+	answers, err := netbios.Query(name, netbios.SuffixServer, target, info.Broadcast, 2*time.Second)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	for _, a := range answers {
+		for _, ip := range a.Addresses {
+			info.Addresses = append(info.Addresses, ip.String())
+		}
+	}
+	return info
+}
+
+func runNetBIOSSet() error {
+	if !samba.IsRoot() {
+		return fmt.Errorf("this command requires root privileges. Please run with sudo")
+	}
+	if netbiosName == "" && netbiosWorkgroup == "" {
+		return fmt.Errorf("nothing to change: use --name and/or --workgroup")
+	}
+
+	name := strings.ToUpper(netbiosName)
+	workgroup := strings.ToUpper(netbiosWorkgroup)
+	if name != "" {
+		if err := netbios.ValidateName(name); err != nil {
+			return fmt.Errorf("invalid NetBIOS name: %v", err)
+		}
+	}
+	if workgroup != "" {
+		if err := netbios.ValidateName(workgroup); err != nil {
+			return fmt.Errorf("invalid workgroup: %v", err)
+		}
+	}
+
+	if err := samba.BackupConfig(); err != nil {
+		return err
+	}
+	if err := samba.SetIdentity(name, workgroup); err != nil {
+		return fmt.Errorf("failed to update smb.conf: %v", err)
+	}
+	if name != "" {
+		fmt.Printf("✓ netbios name = %s\n", name)
+	}
+	if workgroup != "" {
+		fmt.Printf("✓ workgroup = %s\n", workgroup)
+	}
+
+	if err := samba.RestartSamba(); err != nil {
+		return err
+	}
+	return samba.RestartNmbd()
+}
+
+func runNetBIOSEnable() error {
+	if !samba.IsRoot() {
+		return fmt.Errorf("this command requires root privileges. Please run with sudo")
+	}
+	if !samba.IsNmbdInstalled() {
+		return fmt.Errorf("nmbd is not installed; install the Samba package that provides it")
+	}
+
+	if err := samba.EnableNmbd(); err != nil {
+		return err
+	}
+	fmt.Printf("✓ %s enabled and started\n", samba.GetNmbdServiceName())
+	return nil
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/matheusc457/ps2smb/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var outputOpts output.Options
+
+// addOutputFlags registers --output and --format on a command
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&outputOpts.Format, "output", "o", output.FormatText, "Output format: text, json or yaml")
+	cmd.Flags().StringVar(&outputOpts.Template, "format", "", "Format output using a Go template (e.g. '{{.IP}}')")
+}
+
+// render writes a command result using the selected structured format
+func render(v any) error {
+	return output.Render(os.Stdout, outputOpts, v)
+}
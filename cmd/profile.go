@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/netconf"
+	"github.com/matheusc457/ps2smb/internal/network"
+	"github.com/matheusc457/ps2smb/internal/samba"
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileInterface string
+	profileBackend   string
+	profileMode      string
+	profileAddress   string
+	profileYes       bool
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage a persistent network profile for the PS2 link",
+	Long: `Generates a NetworkManager keyfile or a systemd-networkd .network file that
+gives the PS2-facing interface a static address on every boot. In shared mode
+the PS2 also receives its address over DHCP from NetworkManager or networkd.`,
+	Example: `  # Preview the profile for enp3s0
+  ps2smb profile show --interface enp3s0
+
+  # Install it (shows a diff and asks for confirmation)
+  sudo ps2smb profile install --interface enp3s0
+
+  # Remove the installed profile
+  sudo ps2smb profile remove`,
+}
+
+var profileShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the generated profile without installing it",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runProfileShow(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var profileInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the profile after showing a diff",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runProfileInstall(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove the installed profile",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runProfileRemove(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileShowCmd, profileInstallCmd, profileRemoveCmd)
+
+	for _, c := range []*cobra.Command{profileShowCmd, profileInstallCmd} {
+		c.Flags().StringVarP(&profileInterface, "interface", "i", "", "Network interface the PS2 is plugged into (required)")
+		c.Flags().StringVarP(&profileBackend, "backend", "b", "auto", "Profile type: auto, networkmanager or networkd")
+		c.Flags().StringVarP(&profileMode, "mode", "m", netconf.ModeShared, "shared (serve DHCP to the PS2) or manual (static address only)")
+		c.Flags().StringVarP(&profileAddress, "address", "a", "", "Address for this PC (default: current address or "+defaultLinkAddress+")")
+		c.MarkFlagRequired("interface")
+	}
+	profileInstallCmd.Flags().BoolVarP(&profileYes, "yes", "y", false, "Install without asking")
+}
+
+// profileSubnet uses the interface's current address unless one is given,
+// falling back to the same default as 'ps2smb direct'
+func profileSubnet() (*network.Subnet, error) {
+	iface, err := network.GetInterface(profileInterface)
+	if err != nil {
+		return nil, fmt.Errorf("interface %s not found: %v", profileInterface, err)
+	}
+
+	address := profileAddress
+	if address == "" {
+		if addrs := iface.IPv4(); len(addrs) > 0 {
+			return network.SubnetFor(iface.Name, addrs[0].IP)
+		}
+		address = defaultLinkAddress
+	}
+
+	ip, ipnet, err := net.ParseCIDR(address)
+	if err != nil || ip.To4() == nil {
+		return nil, fmt.Errorf("invalid address %q, expected IPv4 CIDR like %s", address, defaultLinkAddress)
+	}
+	prefix, _ := ipnet.Mask.Size()
+	subnet := network.NewSubnet(network.Address{IP: ip, PrefixLen: prefix})
+	subnet.Interface = iface.Name
+	return subnet, nil
+}
+
+func generateProfile() (*netconf.Profile, error) {
+	backend, err := netconf.ParseBackend(profileBackend)
+	if err != nil {
+		return nil, err
+	}
+	subnet, err := profileSubnet()
+	if err != nil {
+		return nil, err
+	}
+	return netconf.Generate(backend, profileInterface, subnet, profileMode)
+}
+
+func runProfileShow() error {
+	profile, err := generateProfile()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("# %s\n", profile.Path)
+	fmt.Print(profile.Content)
+	return nil
+}
+
+func runProfileInstall() error {
+	if !samba.IsRoot() {
+		return fmt.Errorf("this command requires root privileges. Please run with sudo")
+	}
+
+	profile, err := generateProfile()
+	if err != nil {
+		return err
+	}
+
+	if route, err := network.DefaultRoute(); err == nil && route.Iface == profileInterface {
+		fmt.Printf("Warning: %s carries the default route; this profile will replace its LAN configuration.\n\n", profileInterface)
+	}
+
+	existing := profile.Existing()
+	if existing == profile.Content {
+		fmt.Printf("%s is already up to date.\n", profile.Path)
+		return nil
+	}
+
+	fmt.Printf("Changes to %s:\n\n", profile.Path)
+	fmt.Print(profile.Diff())
+	fmt.Println()
+
+	if !profileYes && !askYesNo("Install this profile?") {
+		fmt.Println("Installation cancelled.")
+		return nil
+	}
+
+	if err := profile.Install(); err != nil {
+		return err
+	}
+	fmt.Printf("Installed %s\n", profile.Path)
+
+	// Remember the profile so 'ps2smb uninstall' can remove it, even if
+	// activating it fails below
+	if cfg, err := config.Load(); err == nil {
+		cfg.NetworkProfile = profile.Path
+		if err := cfg.Save(); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	if err := profile.Activate(); err != nil {
+		fmt.Printf("Warning: the profile could not be activated yet: %v\n", err)
+		if profile.Backend == netconf.BackendNetworkManager {
+			fmt.Println("NetworkManager brings it up once the PS2 cable is plugged in.")
+		}
+	}
+	return nil
+}
+
+func runProfileRemove() error {
+	if !samba.IsRoot() {
+		return fmt.Errorf("this command requires root privileges. Please run with sudo")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg.NetworkProfile == "" {
+		fmt.Println("No network profile is installed.")
+		return nil
+	}
+
+	if err := netconf.Remove(cfg.NetworkProfile); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %s\n", cfg.NetworkProfile)
+
+	cfg.NetworkProfile = ""
+	return cfg.Save()
+}
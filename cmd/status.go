@@ -2,126 +2,116 @@ package cmd
 
 import (
 	"fmt"
-	"net"
 	"os"
-	"time"
 
-	"github.com/matheusc457/ps2smb/internal/config"
-	"github.com/matheusc457/ps2smb/internal/samba"
+	"github.com/matheusc457/ps2smb/internal/health"
+	"github.com/matheusc457/ps2smb/internal/output"
 	"github.com/spf13/cobra"
 )
 
+var (
+	statusJSON    bool
+	statusDeep    bool
+	statusNetBIOS bool
+)
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Check PS2 SMB server status",
-	Long:  `Performs health checks on the Samba server and configuration to ensure everything is ready for PS2 connection.`,
+	Long: `Performs health checks on the Samba server and configuration to ensure everything is ready for PS2 connection.
+
+The live share definition in smb.conf is compared against the saved
+configuration and every drifted setting is reported.
+
+With --deep, a built-in SMB1 client logs in to the share exactly as OPL
+does (guest, or NTLMv1 as the configured user) and lists DVD/ and CD/.
+For user authentication the password is read from PS2SMB_PASSWORD.
+
+With --netbios, the NetBIOS name, the nmbd service and a broadcast name
+query are checked too, for setups where OPL connects by name.
+
+Exit codes:
+  0  all checks passed
+  1  warnings only
+  2  at least one critical finding
+  3  status could not be determined`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := runStatus(); err != nil {
+		code, err := runStatus()
+		if err != nil {
 			fmt.Printf("Error: %v\n", err)
-			os.Exit(1)
+			os.Exit(health.ExitUnknown)
 		}
+		os.Exit(code)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Print findings as JSON (same as --output json)")
+	statusCmd.Flags().BoolVar(&statusDeep, "deep", false, "Log in to the share over SMB1 and list the game folders")
+	statusCmd.Flags().BoolVar(&statusNetBIOS, "netbios", false, "Also check the NetBIOS name, nmbd and name resolution")
+	addOutputFlags(statusCmd)
 }
 
-func runStatus() error {
-	fmt.Println("PS2SMB Status Check")
-	fmt.Println("===================")
-	fmt.Println()
-
-	allOK := true
-
-	// Check 1: Is ps2smb configured?
-	fmt.Print("Configuration exists... ")
-	if !config.Exists() {
-		printStatus(false)
-		fmt.Println("  Run 'sudo ps2smb init' to configure")
-		return nil
+func runStatus() (int, error) {
+	if statusJSON {
+		outputOpts.Format = output.FormatJSON
 	}
-	printStatus(true)
-
-	// Load config
-	cfg, err := config.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load configuration: %v", err)
-	}
-
-	// Check 2: Is Samba installed?
-	fmt.Print("Samba installed... ")
-	if !samba.IsSambaInstalled() {
-		printStatus(false)
-		allOK = false
-		fmt.Println("  Install Samba to continue")
-	} else {
-		printStatus(true)
-	}
-
-	// Check 3: Is Samba service running?
-	fmt.Print("Samba service running... ")
-	if !samba.IsSambaRunning() {
-		printStatus(false)
-		allOK = false
-		fmt.Println("  Start with: sudo systemctl start smb")
-	} else {
-		printStatus(true)
+	if err := outputOpts.Validate(); err != nil {
+		return health.ExitUnknown, err
 	}
 
-	// Check 4: Does games directory exist?
-	fmt.Printf("Games directory (%s)... ", cfg.GamesPath)
-	if _, err := os.Stat(cfg.GamesPath); os.IsNotExist(err) {
-		printStatus(false)
-		allOK = false
-		fmt.Printf("  Directory does not exist\n")
-	} else {
-		printStatus(true)
+	report, err := health.Run(health.Options{
+		Deep:     statusDeep,
+		Password: os.Getenv("PS2SMB_PASSWORD"),
+		NetBIOS:  statusNetBIOS,
+	})
+	if err != nil {
+		return health.ExitUnknown, err
 	}
 
-	// Check 5: Do DVD and CD subdirectories exist?
-	fmt.Print("DVD directory... ")
-	dvdPath := cfg.GamesPath + "/DVD"
-	if _, err := os.Stat(dvdPath); os.IsNotExist(err) {
-		printStatus(false)
-		allOK = false
-	} else {
-		printStatus(true)
+	if outputOpts.Structured() {
+		if err := render(report); err != nil {
+			return health.ExitUnknown, err
+		}
+		return report.ExitCode(), nil
 	}
 
-	fmt.Print("CD directory... ")
-	cdPath := cfg.GamesPath + "/CD"
-	if _, err := os.Stat(cdPath); os.IsNotExist(err) {
-		printStatus(false)
-		allOK = false
-	} else {
-		printStatus(true)
-	}
+	fmt.Println("PS2SMB Status Check")
+	fmt.Println("===================")
+	fmt.Println()
 
-	// Check 6: Is port 445 reachable?
-	fmt.Print("Port 445 (SMB) reachable... ")
-	portOpen := checkPort("localhost", 445)
-	if !portOpen {
-		printStatus(false)
-		allOK = false
-		fmt.Println("  Port may be blocked by firewall")
-		fmt.Println("  Open with: sudo ufw allow 445")
-	} else {
-		printStatus(true)
+	// Several drift findings can share one check label; print it once
+	lastCheck := ""
+	for _, f := range report.Findings {
+		if f.Check != lastCheck {
+			fmt.Printf("%s... ", f.Check)
+			printStatus(f.OK())
+			lastCheck = f.Check
+		}
+		if f.Message != "" {
+			fmt.Printf("  %s\n", f.Message)
+		}
+		if f.Fix != "" {
+			fmt.Printf("  %s\n", f.Fix)
+		}
 	}
 
 	// Summary
 	fmt.Println()
 	fmt.Println("Summary:")
 	fmt.Println("--------")
-	if allOK {
+	switch report.Status {
+	case health.SeverityOK:
 		fmt.Println("All checks passed! Your PS2 SMB server is ready.")
 		fmt.Println("\nRun 'ps2smb info' to see connection details.")
-	} else {
+	case health.SeverityWarning:
+		fmt.Println("Some checks reported warnings. Please review the issues above.")
+	default:
 		fmt.Println("Some checks failed. Please fix the issues above.")
 	}
 
-	return nil
+	return report.ExitCode(), nil
 }
 
 func printStatus(ok bool) {
@@ -131,13 +121,3 @@ func printStatus(ok bool) {
 		fmt.Println("✗")
 	}
 }
-
-func checkPort(host string, port int) bool {
-	timeout := 2 * time.Second
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", host, port), timeout)
-	if err != nil {
-		return false
-	}
-	defer conn.Close()
-	return true
-}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/netconf"
+	"github.com/matheusc457/ps2smb/internal/samba"
+	"github.com/spf13/cobra"
+)
+
+var uninstallYes bool
+
+var uninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the PS2 share and everything ps2smb installed",
+	Long: `Removes the PS2 share from smb.conf (after backing it up), the network
+profile installed with 'ps2smb profile install', and the ps2smb configuration.
+Your games directory and the Samba user are left untouched.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runUninstall(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(uninstallCmd)
+	uninstallCmd.Flags().BoolVarP(&uninstallYes, "yes", "y", false, "Uninstall without asking")
+}
+
+func runUninstall() error {
+	if !samba.IsRoot() {
+		return fmt.Errorf("this command requires root privileges. Please run with sudo")
+	}
+	if !config.Exists() {
+		fmt.Println("ps2smb is not configured, nothing to uninstall.")
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	if !uninstallYes && !askYesNo("Remove the PS2 share and ps2smb configuration?") {
+		fmt.Println("Uninstall cancelled.")
+		return nil
+	}
+
+	if cfg.NetworkProfile != "" {
+		fmt.Printf("Removing network profile %s...\n", cfg.NetworkProfile)
+		if err := netconf.Remove(cfg.NetworkProfile); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	fmt.Println("Backing up Samba configuration...")
+	if err := samba.BackupConfig(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	if err := samba.RemovePS2Share(); err != nil {
+		return fmt.Errorf("failed to remove PS2 share: %v", err)
+	}
+
+	if cfg.BindInterface != "" {
+		fmt.Println("Removing interface binding from [global]...")
+		if err := samba.UnbindInterface(cfg.BindInterface); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	if samba.IsSambaRunning() {
+		if err := samba.RestartSamba(); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	if err := config.Remove(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("ps2smb has been uninstalled.")
+	fmt.Printf("Your games in %s were kept.\n", cfg.GamesPath)
+	return nil
+}
@@ -0,0 +1,129 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+// SectorSize is the DVD/CD-ROM mode 1 sector size OPL reads in
+const SectorSize = 2048
+
+// maxReads bounds a single pattern run on very fast storage
+const maxReads = 200000
+
+// Pattern is one OPL-like access pattern
+type Pattern struct {
+	Name        string
+	Description string
+	// ReadSize is the bytes per read, always a whole number of sectors
+	ReadSize int
+	// RunLength is how many consecutive reads happen before seeking to a
+	// random sector; 0 never seeks
+	RunLength int
+}
+
+// Patterns mirrors what OPL does while a game runs
+var Patterns = []Pattern{
+	{Name: "sequential", Description: "16-sector reads in order, like loading a level", ReadSize: 16 * SectorSize},
+	{Name: "small", Description: "Single-sector reads in order, like file system lookups", ReadSize: SectorSize},
+	{Name: "stream", Description: "Seek, then 8 x 16-sector reads, like FMV and audio streaming", ReadSize: 16 * SectorSize, RunLength: 8},
+	{Name: "seek", Description: "Single-sector reads at random sectors", ReadSize: SectorSize, RunLength: 1},
+}
+
+// FindPattern returns the pattern with the given name
+func FindPattern(name string) (Pattern, error) {
+	for _, p := range Patterns {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return Pattern{}, fmt.Errorf("unknown pattern %q", name)
+}
+
+// Latency holds per-read latency percentiles in milliseconds
+type Latency struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// Result is the outcome of running one pattern
+type Result struct {
+	Pattern    string  `json:"pattern"`
+	Reads      int     `json:"reads"`
+	Bytes      int64   `json:"bytes"`
+	Seconds    float64 `json:"seconds"`
+	Throughput float64 `json:"throughput_mib_s"`
+	Latency    Latency `json:"latency_ms"`
+}
+
+// Run replays a pattern against an image of the given size for roughly
+// duration. Each run starts at a random sector so that consecutive patterns
+// do not just re-read each other's cached data
+func Run(r io.ReaderAt, size int64, p Pattern, duration time.Duration) (*Result, error) {
+	sectors := size / SectorSize
+	span := int64(p.ReadSize / SectorSize)
+	if sectors < span {
+		return nil, fmt.Errorf("image is smaller than one %d-byte read", p.ReadSize)
+	}
+
+	rng := rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), uint64(size)))
+	randomSector := func() int64 { return rng.Int64N(sectors - span + 1) }
+
+	buf := make([]byte, p.ReadSize)
+	sector := randomSector()
+	var latencies []time.Duration
+	var bytes int64
+
+	start := time.Now()
+	for i := 0; i < maxReads && time.Since(start) < duration; i++ {
+		if p.RunLength > 0 && i%p.RunLength == 0 {
+			sector = randomSector()
+		}
+		if sector+span > sectors {
+			sector = 0
+		}
+
+		t := time.Now()
+		n, err := r.ReadAt(buf, sector*SectorSize)
+		latencies = append(latencies, time.Since(t))
+		if err != nil && !(err == io.EOF && n == len(buf)) {
+			return nil, fmt.Errorf("read at sector %d: %v", sector, err)
+		}
+
+		bytes += int64(n)
+		sector += span
+	}
+	elapsed := time.Since(start)
+
+	return &Result{
+		Pattern:    p.Name,
+		Reads:      len(latencies),
+		Bytes:      bytes,
+		Seconds:    elapsed.Seconds(),
+		Throughput: float64(bytes) / (1 << 20) / elapsed.Seconds(),
+		Latency:    percentiles(latencies),
+	}, nil
+}
+
+func percentiles(d []time.Duration) Latency {
+	if len(d) == 0 {
+		return Latency{}
+	}
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+
+	rank := func(p float64) float64 {
+		i := int(p*float64(len(d))+0.5) - 1
+		i = max(0, min(i, len(d)-1))
+		return ms(d[i])
+	}
+	return Latency{P50: rank(0.50), P90: rank(0.90), P99: rank(0.99), Max: ms(d[len(d)-1])}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -8,15 +8,18 @@ import (
 )
 
 type Config struct {
-	GamesPath     string `json:"games_path"`
-	ShareName     string `json:"share_name"`
-	UseGuest      bool   `json:"use_guest"`
-	SambaUser     string `json:"samba_user,omitempty"`
-	ConfigVersion string `json:"config_version"`
+	GamesPath      string `json:"games_path"`
+	ShareName      string `json:"share_name"`
+	UseGuest       bool   `json:"use_guest"`
+	SambaUser      string `json:"samba_user,omitempty"`
+	BindInterface  string `json:"bind_interface,omitempty"`
+	HostsAllow     string `json:"hosts_allow,omitempty"`
+	NetworkProfile string `json:"network_profile,omitempty"`
+	ConfigVersion  string `json:"config_version"`
 }
 
-// GetConfigPath returns the path to the config file
-func GetConfigPath() (string, error) {
+// GetConfigDir returns the ps2smb config directory, creating it if needed
+func GetConfigDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -27,6 +30,16 @@ func GetConfigPath() (string, error) {
 		return "", err
 	}
 
+	return configDir, nil
+}
+
+// GetConfigPath returns the path to the config file
+func GetConfigPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+
 	return filepath.Join(configDir, "config.json"), nil
 }
 
@@ -72,6 +85,20 @@ func Load() (*Config, error) {
 	return &config, nil
 }
 
+// Remove deletes the configuration file
+func Remove() error {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return fmt.Errorf("failed to get config path: %v", err)
+	}
+
+	if err := os.Remove(configPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove config: %v", err)
+	}
+
+	return nil
+}
+
 // Exists checks if config file exists
 func Exists() bool {
 	configPath, err := GetConfigPath()
@@ -0,0 +1,159 @@
+package dhcp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// BOOTP operations
+const (
+	opRequest = 1
+	opReply   = 2
+)
+
+// Message types (option 53)
+const (
+	MsgDiscover = 1
+	MsgOffer    = 2
+	MsgRequest  = 3
+	MsgDecline  = 4
+	MsgAck      = 5
+	MsgNak      = 6
+	MsgRelease  = 7
+	MsgInform   = 8
+)
+
+// Option codes used by the server
+const (
+	optPad          = 0
+	optSubnetMask   = 1
+	optRouter       = 3
+	optRequestedIP  = 50
+	optLeaseTime    = 51
+	optMessageType  = 53
+	optServerID     = 54
+	optRenewalTime  = 58
+	optRebindTime   = 59
+	optHostname     = 12
+	optEnd          = 255
+	headerLen       = 236
+	minPacketLen    = 300
+	flagBroadcast   = 0x8000
+	magicCookieSize = 4
+)
+
+var magicCookie = []byte{99, 130, 83, 99}
+
+// Packet is a decoded DHCPv4 message
+type Packet struct {
+	Op      byte
+	XID     uint32
+	Flags   uint16
+	CIAddr  net.IP
+	YIAddr  net.IP
+	SIAddr  net.IP
+	GIAddr  net.IP
+	CHAddr  net.HardwareAddr
+	Options map[byte][]byte
+}
+
+// MessageType returns the value of option 53, or 0 if missing
+func (p *Packet) MessageType() byte {
+	if v := p.Options[optMessageType]; len(v) == 1 {
+		return v[0]
+	}
+	return 0
+}
+
+// IPOption returns an IPv4 option value, or nil if missing or malformed
+func (p *Packet) IPOption(code byte) net.IP {
+	if v := p.Options[code]; len(v) == 4 {
+		return net.IP(v)
+	}
+	return nil
+}
+
+// Hostname returns the client-supplied hostname (option 12), if any
+func (p *Packet) Hostname() string {
+	return string(p.Options[optHostname])
+}
+
+// ParsePacket decodes a DHCPv4 message
+func ParsePacket(b []byte) (*Packet, error) {
+	if len(b) < headerLen+magicCookieSize {
+		return nil, fmt.Errorf("packet too short (%d bytes)", len(b))
+	}
+	if string(b[headerLen:headerLen+4]) != string(magicCookie) {
+		return nil, fmt.Errorf("missing DHCP magic cookie")
+	}
+
+	hlen := int(b[2])
+	if hlen > 16 {
+		return nil, fmt.Errorf("invalid hardware address length %d", hlen)
+	}
+
+	p := &Packet{
+		Op:      b[0],
+		XID:     binary.BigEndian.Uint32(b[4:8]),
+		Flags:   binary.BigEndian.Uint16(b[10:12]),
+		CIAddr:  net.IP(append([]byte{}, b[12:16]...)),
+		YIAddr:  net.IP(append([]byte{}, b[16:20]...)),
+		SIAddr:  net.IP(append([]byte{}, b[20:24]...)),
+		GIAddr:  net.IP(append([]byte{}, b[24:28]...)),
+		CHAddr:  net.HardwareAddr(append([]byte{}, b[28:28+hlen]...)),
+		Options: make(map[byte][]byte),
+	}
+
+	opts := b[headerLen+4:]
+	for i := 0; i < len(opts); {
+		code := opts[i]
+		if code == optEnd {
+			break
+		}
+		if code == optPad {
+			i++
+			continue
+		}
+		if i+1 >= len(opts) {
+			return nil, fmt.Errorf("truncated option %d", code)
+		}
+		length := int(opts[i+1])
+		if i+2+length > len(opts) {
+			return nil, fmt.Errorf("truncated option %d", code)
+		}
+		p.Options[code] = append(p.Options[code], opts[i+2:i+2+length]...)
+		i += 2 + length
+	}
+
+	return p, nil
+}
+
+// Marshal encodes the packet, padding it to the BOOTP minimum size
+func (p *Packet) Marshal(order []byte) []byte {
+	b := make([]byte, headerLen, minPacketLen)
+	b[0] = p.Op
+	b[1] = 1 // Ethernet
+	b[2] = byte(len(p.CHAddr))
+	binary.BigEndian.PutUint32(b[4:8], p.XID)
+	binary.BigEndian.PutUint16(b[10:12], p.Flags)
+	copy(b[12:16], p.CIAddr.To4())
+	copy(b[16:20], p.YIAddr.To4())
+	copy(b[20:24], p.SIAddr.To4())
+	copy(b[24:28], p.GIAddr.To4())
+	copy(b[28:44], p.CHAddr)
+
+	b = append(b, magicCookie...)
+	for _, code := range order {
+		if v, ok := p.Options[code]; ok {
+			b = append(b, code, byte(len(v)))
+			b = append(b, v...)
+		}
+	}
+	b = append(b, optEnd)
+
+	for len(b) < minPacketLen {
+		b = append(b, optPad)
+	}
+	return b
+}
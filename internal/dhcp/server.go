@@ -0,0 +1,265 @@
+package dhcp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Lease is an address handed to a client
+type Lease struct {
+	MAC      net.HardwareAddr
+	IP       net.IP
+	Hostname string
+	Expires  time.Time
+}
+
+// Server is a minimal DHCPv4 server for a single point-to-point link.
+// It only answers on the interface it is bound to.
+type Server struct {
+	Interface string
+	ServerIP  net.IP
+	Mask      net.IPMask
+	Router    net.IP
+	PoolStart net.IP
+	PoolEnd   net.IP
+	LeaseTime time.Duration
+
+	// OnLease is called whenever a client is acknowledged
+	OnLease func(Lease)
+	// Logf receives diagnostic messages
+	Logf func(format string, args ...any)
+
+	mu     sync.Mutex
+	leases map[string]*Lease
+}
+
+// replyOptions is the order options are written in replies
+var replyOptions = []byte{
+	optMessageType, optServerID, optLeaseTime, optRenewalTime,
+	optRebindTime, optSubnetMask, optRouter,
+}
+
+// Serve listens on UDP port 67 of the bound interface until ctx is cancelled
+func (s *Server) Serve(ctx context.Context) error {
+	if s.leases == nil {
+		s.leases = make(map[string]*Lease)
+	}
+	if s.LeaseTime == 0 {
+		s.LeaseTime = 24 * time.Hour
+	}
+
+	lc := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			var serr error
+			err := c.Control(func(fd uintptr) {
+				if serr = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, s.Interface); serr != nil {
+					return
+				}
+				if serr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_BROADCAST, 1); serr != nil {
+					return
+				}
+				serr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1)
+			})
+			if err != nil {
+				return err
+			}
+			return serr
+		},
+	}
+
+	conn, err := lc.ListenPacket(ctx, "udp4", ":67")
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s:67: %v", s.Interface, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to read DHCP packet: %v", err)
+		}
+
+		req, err := ParsePacket(buf[:n])
+		if err != nil || req.Op != opRequest {
+			continue
+		}
+
+		reply := s.handle(req)
+		if reply == nil {
+			continue
+		}
+
+		// The client has no address yet, so always broadcast the reply
+		dst := &net.UDPAddr{IP: net.IPv4bcast, Port: 68}
+		if _, err := conn.WriteTo(reply.Marshal(replyOptions), dst); err != nil {
+			s.logf("failed to send reply: %v", err)
+		}
+	}
+}
+
+// Leases returns a snapshot of the active leases
+func (s *Server) Leases() []Lease {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []Lease
+	for _, l := range s.leases {
+		result = append(result, *l)
+	}
+	return result
+}
+
+func (s *Server) handle(req *Packet) *Packet {
+	mac := req.CHAddr.String()
+
+	switch req.MessageType() {
+	case MsgDiscover:
+		ip := s.allocate(mac, req.IPOption(optRequestedIP))
+		if ip == nil {
+			s.logf("pool exhausted, ignoring DISCOVER from %s", mac)
+			return nil
+		}
+		s.logf("DISCOVER from %s, offering %s", mac, ip)
+		return s.reply(req, MsgOffer, ip)
+
+	case MsgRequest:
+		if id := req.IPOption(optServerID); id != nil && !id.Equal(s.ServerIP) {
+			// The client picked a different server
+			return nil
+		}
+		requested := req.IPOption(optRequestedIP)
+		if requested == nil {
+			requested = req.CIAddr
+		}
+
+		s.mu.Lock()
+		lease := s.leases[mac]
+		s.mu.Unlock()
+
+		if lease == nil || !lease.IP.Equal(requested) {
+			s.logf("REQUEST from %s for %s rejected", mac, requested)
+			return s.reply(req, MsgNak, nil)
+		}
+
+		s.mu.Lock()
+		lease.Expires = time.Now().Add(s.LeaseTime)
+		lease.Hostname = req.Hostname()
+		acked := *lease
+		s.mu.Unlock()
+
+		if s.OnLease != nil {
+			s.OnLease(acked)
+		}
+		return s.reply(req, MsgAck, lease.IP)
+
+	case MsgRelease, MsgDecline:
+		s.mu.Lock()
+		delete(s.leases, mac)
+		s.mu.Unlock()
+		s.logf("%s released its lease", mac)
+	}
+
+	return nil
+}
+
+// allocate returns the client's existing lease, the requested address if
+// free, or the first free address in the pool
+func (s *Server) allocate(mac string, requested net.IP) net.IP {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lease, ok := s.leases[mac]; ok {
+		return lease.IP
+	}
+
+	inUse := make(map[uint32]bool)
+	now := time.Now()
+	for m, l := range s.leases {
+		if !l.Expires.IsZero() && l.Expires.Before(now) {
+			delete(s.leases, m)
+			continue
+		}
+		inUse[toUint(l.IP)] = true
+	}
+
+	start, end := toUint(s.PoolStart), toUint(s.PoolEnd)
+	pick := func(n uint32) net.IP {
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, n)
+		hw, _ := net.ParseMAC(mac)
+		// Offers are held for a minute until the client confirms them
+		s.leases[mac] = &Lease{MAC: hw, IP: ip, Expires: now.Add(time.Minute)}
+		return ip
+	}
+
+	if requested != nil && requested.To4() != nil {
+		n := toUint(requested)
+		if n >= start && n <= end && !inUse[n] && n != toUint(s.ServerIP) {
+			return pick(n)
+		}
+	}
+	for n := start; n <= end; n++ {
+		if !inUse[n] && n != toUint(s.ServerIP) {
+			return pick(n)
+		}
+	}
+	return nil
+}
+
+func (s *Server) reply(req *Packet, msgType byte, ip net.IP) *Packet {
+	p := &Packet{
+		Op:      opReply,
+		XID:     req.XID,
+		Flags:   req.Flags,
+		CIAddr:  net.IPv4zero,
+		YIAddr:  net.IPv4zero,
+		SIAddr:  s.ServerIP,
+		GIAddr:  req.GIAddr,
+		CHAddr:  req.CHAddr,
+		Options: map[byte][]byte{optMessageType: {msgType}, optServerID: s.ServerIP.To4()},
+	}
+	if msgType == MsgNak {
+		p.SIAddr = net.IPv4zero
+		return p
+	}
+
+	p.YIAddr = ip
+	p.Options[optSubnetMask] = []byte(s.Mask)
+	p.Options[optLeaseTime] = seconds(s.LeaseTime)
+	p.Options[optRenewalTime] = seconds(s.LeaseTime / 2)
+	p.Options[optRebindTime] = seconds(s.LeaseTime * 7 / 8)
+	if s.Router != nil {
+		p.Options[optRouter] = s.Router.To4()
+	}
+	return p
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
+
+func seconds(d time.Duration) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(d/time.Second))
+	return b
+}
+
+func toUint(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
@@ -0,0 +1,129 @@
+package firewall
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Comment tags every rule ps2smb creates so it can find and remove them
+const Comment = "ps2smb"
+
+// Port is a single port/protocol pair used by SMB1
+type Port struct {
+	Number   int
+	Protocol string
+}
+
+func (p Port) String() string {
+	return fmt.Sprintf("%d/%s", p.Number, p.Protocol)
+}
+
+// SMBPorts are the ports OPL uses: NetBIOS name and datagram services plus
+// SMB over NetBIOS session (139) and direct SMB (445)
+var SMBPorts = []Port{
+	{137, "udp"},
+	{138, "udp"},
+	{139, "tcp"},
+	{445, "tcp"},
+}
+
+// Firewall is a host firewall frontend that can be inspected and changed.
+// source is a CIDR subnet or address; an empty source means any host.
+type Firewall interface {
+	// Name returns the frontend's name as shown to the user
+	Name() string
+	// Allowed reports which SMB ports accept traffic from source
+	Allowed(source string) (map[Port]bool, error)
+	// Open adds rules allowing SMB from source
+	Open(source string) error
+	// Close removes the rules added by Open
+	Close(source string) error
+	// Persistent reports whether rules survive a reboot
+	Persistent() bool
+}
+
+// Detect returns the active firewall, or nil if no firewall is filtering
+// incoming traffic. Frontends are checked from most to least specific,
+// since ufw and firewalld are themselves built on nftables or iptables.
+// A frontend that is installed but cannot be queried, usually for lack of
+// root privileges, is an error unless another one is found active.
+func Detect() (Firewall, error) {
+	detectors := []func() (Firewall, error){
+		detectUFW,
+		detectFirewalld,
+		detectNftables,
+		detectIptables,
+	}
+	var firstErr error
+	for _, detect := range detectors {
+		fw, err := detect()
+		if fw != nil {
+			return fw, nil
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// AllAllowed reports whether every SMB port is open in the result of Allowed
+func AllAllowed(allowed map[Port]bool) bool {
+	for _, p := range SMBPorts {
+		if !allowed[p] {
+			return false
+		}
+	}
+	return true
+}
+
+func output(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("%s %s failed: %v: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+func installed(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// inPortList reports whether port is in a list such as "445", "137,138",
+// "{ 137, 138 }" or a range written "137-139" or "137:139"
+func inPortList(list string, port int) bool {
+	list = strings.Trim(list, "{} ")
+	for _, item := range strings.Split(list, ",") {
+		bounds := strings.FieldsFunc(strings.TrimSpace(item), func(r rune) bool { return r == '-' || r == ':' })
+		if len(bounds) == 0 || len(bounds) > 2 {
+			continue
+		}
+		lo, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			continue
+		}
+		hi := lo
+		if len(bounds) == 2 {
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				continue
+			}
+		}
+		if port >= lo && port <= hi {
+			return true
+		}
+	}
+	return false
+}
+
+func portsByProtocol(proto string) []string {
+	var ports []string
+	for _, p := range SMBPorts {
+		if p.Protocol == proto {
+			ports = append(ports, fmt.Sprint(p.Number))
+		}
+	}
+	return ports
+}
@@ -0,0 +1,98 @@
+package firewall
+
+import (
+	"fmt"
+	"strings"
+)
+
+type firewalld struct{}
+
+// detectFirewalld needs no privileges: --state only fails when the daemon
+// is not running
+func detectFirewalld() (Firewall, error) {
+	if !installed("firewall-cmd") {
+		return nil, nil
+	}
+	out, err := output("firewall-cmd", "--state")
+	if err != nil || strings.TrimSpace(out) != "running" {
+		return nil, nil
+	}
+	return firewalld{}, nil
+}
+
+func (firewalld) Name() string     { return "firewalld" }
+func (firewalld) Persistent() bool { return true }
+
+func (firewalld) Allowed(source string) (map[Port]bool, error) {
+	allowed := make(map[Port]bool)
+
+	services, err := output("firewall-cmd", "--list-services")
+	if err != nil {
+		return nil, err
+	}
+	ports, err := output("firewall-cmd", "--list-ports")
+	if err != nil {
+		return nil, err
+	}
+	rich, err := output("firewall-cmd", "--list-rich-rules")
+	if err != nil {
+		return nil, err
+	}
+
+	samba := containsField(services, "samba")
+	for _, p := range SMBPorts {
+		if samba || containsField(ports, p.String()) {
+			allowed[p] = true
+		}
+	}
+
+	if source != "" {
+		for _, rule := range strings.Split(rich, "\n") {
+			if strings.Contains(rule, fmt.Sprintf("source address=%q", source)) &&
+				strings.Contains(rule, `service name="samba"`) && strings.Contains(rule, "accept") {
+				for _, p := range SMBPorts {
+					allowed[p] = true
+				}
+			}
+		}
+	}
+	return allowed, nil
+}
+
+func (f firewalld) Open(source string) error {
+	if source == "" {
+		if _, err := output("firewall-cmd", "--permanent", "--add-service=samba"); err != nil {
+			return err
+		}
+	} else if _, err := output("firewall-cmd", "--permanent", "--add-rich-rule="+richRule(source)); err != nil {
+		return err
+	}
+	_, err := output("firewall-cmd", "--reload")
+	return err
+}
+
+func (f firewalld) Close(source string) error {
+	if source == "" {
+		if _, err := output("firewall-cmd", "--permanent", "--remove-service=samba"); err != nil {
+			return err
+		}
+	} else if _, err := output("firewall-cmd", "--permanent", "--remove-rich-rule="+richRule(source)); err != nil {
+		return err
+	}
+	_, err := output("firewall-cmd", "--reload")
+	return err
+}
+
+// richRule scopes firewalld's samba service (137-139, 445) to one source
+func richRule(source string) string {
+	return fmt.Sprintf(`rule family="ipv4" source address=%q service name="samba" accept`, source)
+}
+
+func containsField(s, field string) bool {
+	for _, f := range strings.Fields(s) {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package firewall
+
+import (
+	"fmt"
+	"strings"
+)
+
+type iptables struct {
+	policy string
+}
+
+func detectIptables() (Firewall, error) {
+	if !installed("iptables") {
+		return nil, nil
+	}
+	out, err := output("iptables", "-S", "INPUT")
+	if err != nil {
+		return nil, err
+	}
+
+	policy := "ACCEPT"
+	restrictive := false
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "-P" {
+			policy = fields[2]
+		}
+		if strings.Contains(line, "-j DROP") || strings.Contains(line, "-j REJECT") {
+			restrictive = true
+		}
+	}
+	if policy == "ACCEPT" && !restrictive {
+		return nil, nil
+	}
+	return &iptables{policy: policy}, nil
+}
+
+func (*iptables) Name() string { return "iptables" }
+
+// Rules added with iptables are lost on reboot unless saved with iptables-save
+func (*iptables) Persistent() bool { return false }
+
+// Allowed walks the INPUT chain in order: the first ACCEPT, DROP or REJECT
+// rule that applies to a port decides, and the policy decides otherwise.
+// Jumps to other chains are not followed
+func (i *iptables) Allowed(source string) (map[Port]bool, error) {
+	out, err := output("iptables", "-S", "INPUT")
+	if err != nil {
+		return nil, err
+	}
+
+	policy := i.policy
+	var rules [][]string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 3 && fields[0] == "-P":
+			policy = fields[2]
+		case len(fields) > 2 && fields[0] == "-A":
+			rules = append(rules, fields[2:])
+		}
+	}
+
+	allowed := make(map[Port]bool)
+	for _, p := range SMBPorts {
+		allowed[p] = policy == "ACCEPT"
+		for _, rule := range rules {
+			target, ok := iptablesTarget(rule, p, source)
+			if ok {
+				allowed[p] = target == "ACCEPT"
+				break
+			}
+		}
+	}
+	return allowed, nil
+}
+
+// iptablesTarget returns the verdict of a rule in 'iptables -S' form if it
+// applies to new traffic to p from source. Rules with other conditions
+// (interfaces, connection state, negations) are treated as not applying
+func iptablesTarget(rule []string, p Port, source string) (string, bool) {
+	for k := 0; k < len(rule); k++ {
+		opt := rule[k]
+		if k+1 >= len(rule) {
+			return "", false
+		}
+		k++
+		value := rule[k]
+		switch opt {
+		case "-s":
+			if source == "" || (value != source && value != source+"/32") {
+				return "", false
+			}
+		case "-p":
+			if value != p.Protocol {
+				return "", false
+			}
+		case "-m":
+			if value != p.Protocol && value != "multiport" && value != "comment" {
+				return "", false
+			}
+		case "--dport", "--dports":
+			if !inPortList(value, p.Number) {
+				return "", false
+			}
+		case "--comment":
+			// Comments with spaces are quoted across several fields
+			for strings.HasPrefix(value, `"`) && !strings.HasSuffix(value, `"`) && k+1 < len(rule) {
+				k++
+				value = rule[k]
+			}
+		case "-j":
+			if value == "ACCEPT" || value == "DROP" || value == "REJECT" {
+				return value, true
+			}
+			return "", false
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+func (i *iptables) Open(source string) error {
+	for _, p := range SMBPorts {
+		if _, err := output("iptables", append([]string{"-I", "INPUT"}, ruleSpec(source, p)...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close deletes the rule of every port, carrying on past rules that are
+// already gone so a partly closed set can still be removed
+func (i *iptables) Close(source string) error {
+	var firstErr error
+	for _, p := range SMBPorts {
+		out, err := output("iptables", append([]string{"-D", "INPUT"}, ruleSpec(source, p)...)...)
+		if err != nil && strings.Contains(out, "does a matching rule exist") {
+			continue
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func ruleSpec(source string, p Port) []string {
+	var spec []string
+	if source != "" {
+		spec = append(spec, "-s", source)
+	}
+	return append(spec,
+		"-p", p.Protocol, "--dport", fmt.Sprint(p.Number),
+		"-m", "comment", "--comment", Comment,
+		"-j", "ACCEPT")
+}
@@ -0,0 +1,223 @@
+package firewall
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// nftables adds rules to the existing base chain hooked on input, since an
+// accept in a separate table cannot override a drop elsewhere
+type nftables struct {
+	family string
+	table  string
+	chain  string
+	policy string
+}
+
+var nftHandle = regexp.MustCompile(`# handle (\d+)`)
+
+func detectNftables() (Firewall, error) {
+	if !installed("nft") {
+		return nil, nil
+	}
+	out, err := output("nft", "list", "ruleset")
+	if err != nil {
+		return nil, err
+	}
+
+	fw, rules, ok := findInputChain(out)
+	if !ok {
+		return nil, nil
+	}
+	// An accept-all chain with no drop/reject rules of its own filters nothing
+	if fw.policy == "accept" && !hasDrop(rules) {
+		return nil, nil
+	}
+	return fw, nil
+}
+
+// findInputChain locates the first filter chain hooked on input and returns
+// it with its rules
+func findInputChain(ruleset string) (*nftables, []string, bool) {
+	var family, table, chain string
+	var found *nftables
+	var rules []string
+
+	scanner := bufio.NewScanner(strings.NewReader(ruleset))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if found != nil {
+			if line == "}" {
+				break
+			}
+			rules = append(rules, line)
+			continue
+		}
+		switch {
+		case fields[0] == "table" && len(fields) >= 3:
+			family, table = fields[1], fields[2]
+		case fields[0] == "chain" && len(fields) >= 2:
+			chain = fields[1]
+		case fields[0] == "type" && strings.Contains(line, "hook input"):
+			if family != "inet" && family != "ip" {
+				continue
+			}
+			policy := "accept"
+			if i := strings.Index(line, "policy "); i >= 0 {
+				policy = strings.Trim(strings.Fields(line[i+7:])[0], ";")
+			}
+			found = &nftables{family: family, table: table, chain: chain, policy: policy}
+		}
+	}
+	return found, rules, found != nil
+}
+
+func hasDrop(rules []string) bool {
+	for _, rule := range rules {
+		if v := nftVerdict(strings.Fields(rule)); v == "drop" || v == "reject" {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *nftables) Name() string {
+	return fmt.Sprintf("nftables (%s %s %s)", n.family, n.table, n.chain)
+}
+
+// Rules added with nft are lost on reboot unless saved to /etc/nftables.conf
+func (n *nftables) Persistent() bool { return false }
+
+// Allowed walks the chain in order: the first accept, drop or reject rule
+// that applies to a port decides, and the policy decides otherwise. Jumps
+// to other chains are not followed
+func (n *nftables) Allowed(source string) (map[Port]bool, error) {
+	out, err := output("nft", "list", "chain", n.family, n.table, n.chain)
+	if err != nil {
+		return nil, err
+	}
+	_, rules, _ := findInputChain(out)
+
+	allowed := make(map[Port]bool)
+	for _, p := range SMBPorts {
+		allowed[p] = n.policy == "accept"
+		for _, rule := range rules {
+			verdict, ok := nftApplies(rule, p, source)
+			if ok {
+				allowed[p] = verdict == "accept"
+				break
+			}
+		}
+	}
+	return allowed, nil
+}
+
+// nftVerdict returns the first accept, drop or reject statement of a rule
+func nftVerdict(fields []string) string {
+	for _, f := range fields {
+		if f == "accept" || f == "drop" || f == "reject" {
+			return f
+		}
+	}
+	return ""
+}
+
+// nftApplies returns the verdict of a rule if it applies to new traffic to
+// p from source. Rules with other conditions (interfaces, connection
+// state, negations) are treated as not applying
+func nftApplies(rule string, p Port, source string) (string, bool) {
+	fields := strings.Fields(rule)
+	verdict := nftVerdict(fields)
+	if verdict == "" {
+		return "", false
+	}
+
+	for k := 0; k < len(fields); k++ {
+		f := fields[k]
+		switch {
+		case f == verdict:
+			return verdict, true
+		case f == "counter":
+			if k+4 < len(fields) && fields[k+1] == "packets" {
+				k += 4
+			}
+			continue
+		}
+
+		// Every other statement is a two-word key followed by a value,
+		// which may be a set spanning several fields
+		if k+2 >= len(fields) {
+			return "", false
+		}
+		key := f + " " + fields[k+1]
+		value := fields[k+2]
+		k += 2
+		if value == "{" {
+			for k+1 < len(fields) && fields[k] != "}" {
+				k++
+				value += " " + fields[k]
+			}
+		}
+		switch key {
+		case "ip saddr":
+			if source == "" || value != source {
+				return "", false
+			}
+		case "meta l4proto", "ip protocol":
+			if !strings.Contains(value, p.Protocol) {
+				return "", false
+			}
+		case "tcp dport", "udp dport":
+			if f != p.Protocol || !inPortList(value, p.Number) {
+				return "", false
+			}
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+func (n *nftables) Open(source string) error {
+	for _, proto := range []string{"udp", "tcp"} {
+		rule := []string{"insert", "rule", n.family, n.table, n.chain}
+		if source != "" {
+			rule = append(rule, "ip", "saddr", source)
+		}
+		rule = append(rule, proto, "dport", "{", strings.Join(portsByProtocol(proto), ","), "}",
+			"accept", "comment", fmt.Sprintf("%q", Comment))
+		if _, err := output("nft", rule...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *nftables) Close(source string) error {
+	out, err := output("nft", "-a", "list", "chain", n.family, n.table, n.chain)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.Contains(line, fmt.Sprintf("comment %q", Comment)) {
+			continue
+		}
+		if source != "" && !strings.Contains(line, "saddr "+source) {
+			continue
+		}
+		m := nftHandle.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if _, err := output("nft", "delete", "rule", n.family, n.table, n.chain, "handle", m[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
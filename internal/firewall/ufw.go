@@ -0,0 +1,90 @@
+package firewall
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ufw struct{}
+
+func detectUFW() (Firewall, error) {
+	if !installed("ufw") {
+		return nil, nil
+	}
+	out, err := output("ufw", "status")
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(out, "Status: active") {
+		return nil, nil
+	}
+	return ufw{}, nil
+}
+
+func (ufw) Name() string     { return "ufw" }
+func (ufw) Persistent() bool { return true }
+
+func (ufw) Allowed(source string) (map[Port]bool, error) {
+	out, err := output("ufw", "status")
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[Port]bool)
+	for _, line := range strings.Split(out, "\n") {
+		// Drop the rule comment: "445/tcp  ALLOW  10.0.0.0/24  # ps2smb"
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.Contains(line, "ALLOW") || strings.Contains(line, "(v6)") {
+			continue
+		}
+		from := fields[len(fields)-1]
+		if from != "Anywhere" && source != "" && from != source {
+			continue
+		}
+
+		target := fields[0]
+		for _, p := range SMBPorts {
+			if target == "Samba" || target == p.String() || target == fmt.Sprint(p.Number) {
+				allowed[p] = true
+			}
+		}
+	}
+	return allowed, nil
+}
+
+func (u ufw) Open(source string) error {
+	for _, p := range SMBPorts {
+		if _, err := output("ufw", u.args("allow", source, p)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close deletes the rule of every port, carrying on past rules that are
+// already gone so a partly closed set can still be removed
+func (u ufw) Close(source string) error {
+	var firstErr error
+	for _, p := range SMBPorts {
+		args := append([]string{"delete"}, u.args("allow", source, p)...)
+		out, err := output("ufw", args...)
+		if strings.Contains(out, "non-existent rule") {
+			continue
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (ufw) args(action, source string, p Port) []string {
+	from := source
+	if from == "" {
+		from = "any"
+	}
+	return []string{action, "from", from, "to", "any", "port", fmt.Sprint(p.Number), "proto", p.Protocol, "comment", Comment}
+}
@@ -0,0 +1,112 @@
+package health
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/smb1"
+)
+
+// gameDirs are the share folders OPL scans for disc images
+var gameDirs = []string{"DVD", "CD"}
+
+// checkShareAccess walks the same path OPL takes on boot: session setup,
+// tree connect to the share and a directory listing of DVD/ and CD/
+func checkShareAccess(cfg *config.Config, ip net.IP, password string) []Finding {
+	user, desc := smb1.GuestUser, "guest"
+	if !cfg.UseGuest {
+		user, desc = cfg.SambaUser, "user "+cfg.SambaUser
+	}
+	check := fmt.Sprintf(`Share \\%s\%s accessible as %s`, ip, cfg.ShareName, desc)
+
+	if !cfg.UseGuest && password == "" {
+		return []Finding{{
+			ID:       "share-login-skipped",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  "No password given for the Samba user; login was not verified",
+			Fix:      "Re-run with the password in PS2SMB_PASSWORD",
+		}}
+	}
+
+	fail := func(id, msg, fix string) []Finding {
+		return []Finding{{ID: id, Check: check, Severity: SeverityCritical, Message: msg, Fix: fix}}
+	}
+
+	conn, err := smb1.Dial(ip.String(), 445, 5*time.Second)
+	if err != nil {
+		return fail("share-connect-failed", fmt.Sprintf("Could not connect: %v", err), "")
+	}
+	defer conn.Close()
+
+	if _, err := conn.Negotiate(); err != nil {
+		return fail("share-connect-failed", fmt.Sprintf("Negotiation failed: %v", err), "")
+	}
+
+	guest, err := conn.SessionSetup(user, password)
+	if err != nil {
+		fix := "Check the password with: sudo smbpasswd -a " + cfg.SambaUser
+		if cfg.UseGuest {
+			fix = "Set 'map to guest = Bad User' in [global] with: sudo ps2smb fix"
+		}
+		return fail("share-login-failed", fmt.Sprintf("Session setup failed: %v", err), fix)
+	}
+
+	if err := conn.TreeConnect(ip.String(), cfg.ShareName); err != nil {
+		fix := "Run 'sudo ps2smb fix' to restore the share definition"
+		if cfg.UseGuest {
+			fix = "Make sure the share has 'guest ok = yes': sudo ps2smb fix"
+		}
+		return fail("share-tree-connect-failed", fmt.Sprintf("Tree connect failed: %v", err), fix)
+	}
+
+	findings := []Finding{pass("share-access", check)}
+	if guest && !cfg.UseGuest {
+		findings[0] = Finding{
+			ID:       "share-login-guest",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  "The server mapped the login to guest instead of " + cfg.SambaUser,
+			Fix:      "Check the password with: sudo smbpasswd -a " + cfg.SambaUser,
+		}
+	}
+
+	for _, dir := range gameDirs {
+		findings = append(findings, checkListing(conn, dir))
+	}
+	return findings
+}
+
+// checkListing lists a game folder over SMB and counts the images OPL
+// would show
+func checkListing(conn *smb1.Conn, dir string) Finding {
+	check := fmt.Sprintf("%s/ listed over SMB", dir)
+	id := "share-list-" + strings.ToLower(dir)
+
+	files, err := conn.List(dir)
+	if err != nil {
+		return Finding{
+			ID:       id + "-failed",
+			Check:    check,
+			Severity: SeverityCritical,
+			Message:  fmt.Sprintf("Listing failed: %v", err),
+			Fix:      "Check the folder exists and is readable by the Samba user",
+		}
+	}
+
+	games := 0
+	for _, f := range files {
+		ext := strings.ToLower(filepath.Ext(f.Name))
+		if !f.IsDir && (ext == ".iso" || ext == ".zso") {
+			games++
+		}
+	}
+
+	f := pass(id, check)
+	f.Message = fmt.Sprintf("%d game image(s) visible", games)
+	return f
+}
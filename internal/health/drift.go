@@ -0,0 +1,216 @@
+package health
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/network"
+	"github.com/matheusc457/ps2smb/internal/samba"
+)
+
+// checkShareDrift compares the live share definition in smb.conf against
+// config.json and reports every setting that no longer matches
+func checkShareDrift(cfg *config.Config) []Finding {
+	const check = "Samba share matches configuration"
+	reinit := "Re-run 'sudo ps2smb init' to rewrite the share"
+	remedy := rewriteShareRemedy(cfg)
+
+	conf, err := samba.LoadConfig()
+	if err != nil {
+		return []Finding{{
+			ID:       "smbconf-unreadable",
+			Check:    check,
+			Severity: SeverityCritical,
+			Message:  fmt.Sprintf("Failed to read %s: %v", samba.SmbConfPath, err),
+			Fix:      reinit,
+			Remedy:   remedy,
+		}}
+	}
+
+	share := conf.Section(cfg.ShareName)
+	if share == nil {
+		return []Finding{{
+			ID:       "share-missing",
+			Check:    check,
+			Severity: SeverityCritical,
+			Message:  fmt.Sprintf("[%s] section not found in %s", cfg.ShareName, samba.SmbConfPath),
+			Fix:      reinit,
+			Remedy:   remedy,
+		}}
+	}
+
+	var findings []Finding
+
+	path, _ := share.Get("path")
+	if filepath.Clean(path) != filepath.Clean(cfg.GamesPath) {
+		findings = append(findings, Finding{
+			ID:       "share-path-drift",
+			Check:    check,
+			Severity: SeverityCritical,
+			Message:  fmt.Sprintf("Share path is %q, configuration expects %q", path, cfg.GamesPath),
+			Fix:      reinit,
+			Remedy:   remedy,
+		})
+	}
+
+	guest := share.GetBool("guest ok", false)
+	if guest != cfg.UseGuest {
+		findings = append(findings, Finding{
+			ID:       "share-guest-drift",
+			Check:    check,
+			Severity: SeverityCritical,
+			Message:  fmt.Sprintf("Share has guest ok = %s, configuration expects %s", yesNo(guest), yesNo(cfg.UseGuest)),
+			Fix:      reinit,
+			Remedy:   remedy,
+		})
+	}
+
+	if !cfg.UseGuest && cfg.SambaUser != "" {
+		users, _ := share.Get("valid users")
+		if !containsFold(samba.SplitList(users), cfg.SambaUser) {
+			findings = append(findings, Finding{
+				ID:       "share-valid-users-drift",
+				Check:    check,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("User %s is not listed in valid users (%q)", cfg.SambaUser, users),
+				Fix:      reinit,
+				Remedy:   remedy,
+			})
+		}
+	}
+
+	hosts, _ := share.Get("hosts allow")
+	if strings.Join(strings.Fields(hosts), " ") != cfg.HostsAllow {
+		findings = append(findings, Finding{
+			ID:       "share-hosts-allow-drift",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Share has hosts allow = %q, configuration expects %q", hosts, cfg.HostsAllow),
+			Fix:      reinit,
+			Remedy:   remedy,
+		})
+	}
+
+	if len(findings) == 0 {
+		return []Finding{pass("share", check)}
+	}
+	return findings
+}
+
+// checkExposure warns when the share can be reached from networks other
+// than the one the PS2 is on
+func checkExposure(cfg *config.Config) Finding {
+	const check = "Share limited to the PS2 network"
+
+	conf, err := samba.LoadConfig()
+	if err != nil {
+		conf = &samba.SmbConf{}
+	}
+
+	bound := samba.BoundInterfaces(conf)
+	if cfg.BindInterface != "" && !containsFold(bound, cfg.BindInterface) {
+		return Finding{
+			ID:       "share-bind-drift",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Samba is not bound to %s as configured", cfg.BindInterface),
+			Fix:      "Run 'sudo ps2smb fix' to bind it again",
+			Remedy: &Remedy{
+				ID:          "bind-interface",
+				Description: fmt.Sprintf("Bind Samba to lo and %s and restart it", cfg.BindInterface),
+				Apply: func() error {
+					if err := samba.BindToInterface(cfg.BindInterface); err != nil {
+						return err
+					}
+					return samba.RestartSamba()
+				},
+			},
+		}
+	}
+
+	// Interfaces other than the bound ones that carry an IPv4 address
+	var exposed []string
+	interfaces, _ := network.ListInterfaces()
+	for _, iface := range interfaces {
+		if !iface.Up() || len(iface.IPv4()) == 0 {
+			continue
+		}
+		if bound != nil && !containsFold(bound, iface.Name) {
+			continue
+		}
+		exposed = append(exposed, iface.Name)
+	}
+
+	share := conf.Section(cfg.ShareName)
+	restricted := false
+	if share != nil {
+		_, restricted = share.Get("hosts allow")
+	}
+
+	if len(exposed) > 1 && !restricted {
+		return Finding{
+			ID:       "share-exposed",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Share is reachable from every network on %s", strings.Join(exposed, ", ")),
+			Fix:      "Re-run 'sudo ps2smb init --interface <name>' to bind Samba to the PS2 interface",
+		}
+	}
+	return pass("share-scope", check)
+}
+
+// checkGlobals verifies the [global] settings OPL depends on
+func checkGlobals(cfg *config.Config) Finding {
+	const check = "Global settings for OPL"
+
+	conf, err := samba.LoadConfig()
+	if err != nil {
+		// Already reported by the share drift check
+		conf = &samba.SmbConf{}
+	}
+
+	missing := samba.MissingGlobals(conf, cfg.UseGuest)
+	if len(missing) == 0 {
+		return pass("globals", check)
+	}
+
+	var settings []string
+	for _, p := range missing {
+		settings = append(settings, fmt.Sprintf("%s = %s", p.Key, p.Value))
+	}
+	return Finding{
+		ID:       "globals-drift",
+		Check:    check,
+		Severity: SeverityCritical,
+		Message:  fmt.Sprintf("[global] is missing: %s", strings.Join(settings, ", ")),
+		Fix:      "Run 'sudo ps2smb fix' to restore them",
+		Remedy: &Remedy{
+			ID:          "restore-globals",
+			Description: "Restore SMB1 and authentication settings in [global] and restart Samba",
+			Apply: func() error {
+				if err := samba.ApplyGlobalSettings(cfg.UseGuest); err != nil {
+					return err
+				}
+				return samba.RestartSamba()
+			},
+		},
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,408 @@
+package health
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/firewall"
+	"github.com/matheusc457/ps2smb/internal/network"
+	"github.com/matheusc457/ps2smb/internal/samba"
+	"github.com/matheusc457/ps2smb/internal/smb1"
+)
+
+// Severity describes how serious a finding is
+type Severity string
+
+const (
+	SeverityOK       Severity = "ok"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Exit codes used by 'ps2smb status', following the monitoring plugin convention
+const (
+	ExitOK       = 0
+	ExitWarning  = 1
+	ExitCritical = 2
+	ExitUnknown  = 3
+)
+
+// Finding is the result of a single health check
+type Finding struct {
+	ID       string   `json:"id"`
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message,omitempty"`
+	Fix      string   `json:"fix,omitempty"`
+	Remedy   *Remedy  `json:"remedy,omitempty"`
+}
+
+// Remedy is an automated repair that 'ps2smb fix' can apply for a finding.
+// Several findings may share a remedy with the same ID.
+type Remedy struct {
+	ID          string       `json:"id"`
+	Description string       `json:"description"`
+	Apply       func() error `json:"-"`
+}
+
+// OK reports whether the finding passed
+func (f Finding) OK() bool {
+	return f.Severity == SeverityOK
+}
+
+// Report is the full result of a status run
+type Report struct {
+	Status   Severity  `json:"status"`
+	Findings []Finding `json:"findings"`
+}
+
+// ExitCode maps the overall report status to a process exit code
+func (r *Report) ExitCode() int {
+	switch r.Status {
+	case SeverityOK:
+		return ExitOK
+	case SeverityWarning:
+		return ExitWarning
+	default:
+		return ExitCritical
+	}
+}
+
+func (r *Report) add(findings ...Finding) {
+	for _, f := range findings {
+		r.Findings = append(r.Findings, f)
+		if rank(f.Severity) > rank(r.Status) {
+			r.Status = f.Severity
+		}
+	}
+}
+
+func rank(s Severity) int {
+	switch s {
+	case SeverityWarning:
+		return 1
+	case SeverityCritical:
+		return 2
+	}
+	return 0
+}
+
+func pass(id, check string) Finding {
+	return Finding{ID: id, Check: check, Severity: SeverityOK}
+}
+
+// Options tunes which checks Run performs
+type Options struct {
+	// Deep logs in to the share over SMB1 and lists DVD/ and CD/ like OPL
+	Deep bool
+	// Password authenticates the configured Samba user during deep checks
+	Password string
+	// NetBIOS checks the server name, nmbd and a broadcast name query, for
+	// setups where OPL connects by name instead of IP address
+	NetBIOS bool
+}
+
+// Run performs all health checks against the saved configuration
+func Run(opts Options) (*Report, error) {
+	report := &Report{Status: SeverityOK}
+
+	if !config.Exists() {
+		report.add(Finding{
+			ID:       "config-missing",
+			Check:    "Configuration exists",
+			Severity: SeverityCritical,
+			Message:  "ps2smb has not been configured",
+			Fix:      "Run 'sudo ps2smb init' to configure",
+		})
+		return report, nil
+	}
+	report.add(pass("config", "Configuration exists"))
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	report.add(checkSambaInstalled())
+	report.add(checkSambaRunning())
+	report.add(checkSambaEnabled())
+	report.add(checkGamesDirs(cfg)...)
+	report.add(checkPlacement(cfg)...)
+	report.add(checkShareDrift(cfg)...)
+	report.add(checkGlobals(cfg))
+	report.add(checkExposure(cfg))
+	report.add(checkPorts(cfg, opts)...)
+	report.add(checkFirewall(cfg))
+	if opts.NetBIOS {
+		report.add(checkNetBIOS(cfg)...)
+	}
+
+	return report, nil
+}
+
+func checkSambaInstalled() Finding {
+	const check = "Samba installed"
+	if !samba.IsSambaInstalled() {
+		return Finding{
+			ID:       "samba-not-installed",
+			Check:    check,
+			Severity: SeverityCritical,
+			Message:  "smbd was not found in PATH",
+			Fix:      "Install Samba to continue",
+		}
+	}
+	return pass("samba-installed", check)
+}
+
+func checkSambaRunning() Finding {
+	const check = "Samba service running"
+	if !samba.IsSambaRunning() {
+		service := samba.GetSambaServiceName()
+		return Finding{
+			ID:       "samba-not-running",
+			Check:    check,
+			Severity: SeverityCritical,
+			Message:  "The Samba service is not active",
+			Fix:      fmt.Sprintf("Start with: sudo systemctl start %s", service),
+			Remedy: &Remedy{
+				ID:          "start-samba",
+				Description: fmt.Sprintf("Start the %s service", service),
+				Apply:       samba.StartSamba,
+			},
+		}
+	}
+	return pass("samba-running", check)
+}
+
+func checkSambaEnabled() Finding {
+	const check = "Samba service enabled on boot"
+	if !samba.IsSambaEnabled() {
+		service := samba.GetSambaServiceName()
+		return Finding{
+			ID:       "samba-not-enabled",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  "Samba will not start automatically after a reboot",
+			Fix:      fmt.Sprintf("Enable with: sudo systemctl enable %s", service),
+			Remedy: &Remedy{
+				ID:          "enable-samba",
+				Description: fmt.Sprintf("Enable the %s service on boot", service),
+				Apply:       samba.EnableSamba,
+			},
+		}
+	}
+	return pass("samba-enabled", check)
+}
+
+func checkGamesDirs(cfg *config.Config) []Finding {
+	var findings []Finding
+
+	check := fmt.Sprintf("Games directory (%s)", cfg.GamesPath)
+	if _, err := os.Stat(cfg.GamesPath); os.IsNotExist(err) {
+		findings = append(findings, Finding{
+			ID:       "games-dir-missing",
+			Check:    check,
+			Severity: SeverityCritical,
+			Message:  "Directory does not exist",
+			Fix:      fmt.Sprintf("Create it with: sudo mkdir -p %s", cfg.GamesPath),
+			Remedy:   mkdirRemedy(cfg.GamesPath),
+		})
+	} else {
+		findings = append(findings, pass("games-dir", check))
+	}
+
+	for _, sub := range []string{"DVD", "CD"} {
+		check := sub + " directory"
+		path := filepath.Join(cfg.GamesPath, sub)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			findings = append(findings, Finding{
+				ID:       "games-subdir-missing-" + sub,
+				Check:    check,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s does not exist", path),
+				Fix:      fmt.Sprintf("Create it with: sudo mkdir -p %s", path),
+				Remedy:   mkdirRemedy(path),
+			})
+		} else {
+			findings = append(findings, pass("games-subdir-"+sub, check))
+		}
+	}
+
+	return findings
+}
+
+// advertisedAddresses returns the IPv4 addresses 'ps2smb info' would hand
+// to the PS2: those of the bound interface or of the auto-selected one
+func advertisedAddresses(cfg *config.Config) []net.IP {
+	iface := cfg.BindInterface
+	if iface == "" {
+		best, _, err := network.SelectPrimary()
+		if err != nil {
+			return nil
+		}
+		iface = best.Interface.Name
+	}
+
+	info, err := network.GetInterface(iface)
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, addr := range info.IPv4() {
+		ips = append(ips, addr.IP)
+	}
+	return ips
+}
+
+func checkPorts(cfg *config.Config, opts Options) []Finding {
+	ips := advertisedAddresses(cfg)
+	if len(ips) == 0 {
+		return []Finding{{
+			ID:       "no-address",
+			Check:    "SMB reachable on the PS2 network",
+			Severity: SeverityCritical,
+			Message:  "No IPv4 address found to advertise to the PS2",
+			Fix:      "Check the network with: ps2smb interfaces",
+		}}
+	}
+
+	var findings []Finding
+	for _, ip := range ips {
+		smb := checkPort(ip, 445, "SMB", SeverityCritical)
+		findings = append(findings, smb, checkPort(ip, 139, "NetBIOS session", SeverityWarning))
+		if !smb.OK() {
+			continue
+		}
+		negotiate := checkNegotiate(ip)
+		findings = append(findings, negotiate)
+		if opts.Deep && negotiate.OK() {
+			findings = append(findings, checkShareAccess(cfg, ip, opts.Password)...)
+		}
+	}
+	return findings
+}
+
+func checkPort(ip net.IP, port int, service string, severity Severity) Finding {
+	check := fmt.Sprintf("Port %d (%s) on %s", port, service, ip)
+	id := fmt.Sprintf("port-%d", port)
+
+	state, _ := network.ProbePort(ip, port, 2*time.Second)
+	switch state {
+	case network.PortOpen:
+		return pass(id, check)
+	case network.PortRefused:
+		return Finding{
+			ID:       id + "-refused",
+			Check:    check,
+			Severity: severity,
+			Message:  "Connection refused: smbd is not listening on this address",
+			Fix:      "Check that Samba is running and its 'interfaces' setting includes this address",
+		}
+	case network.PortTimeout:
+		return Finding{
+			ID:       id + "-timeout",
+			Check:    check,
+			Severity: severity,
+			Message:  "Connection timed out: packets are probably dropped by a firewall",
+			Fix:      "Inspect with: sudo ps2smb firewall status",
+		}
+	}
+	return Finding{
+		ID:       id + "-filtered",
+		Check:    check,
+		Severity: severity,
+		Message:  "Connection rejected by a firewall",
+		Fix:      "Open with: sudo ps2smb firewall open",
+	}
+}
+
+// checkNegotiate proves the server speaks the only dialect OPL offers
+func checkNegotiate(ip net.IP) Finding {
+	check := fmt.Sprintf("SMB1 %s negotiation on %s", smb1.Dialect, ip)
+
+	conn, err := smb1.Dial(ip.String(), 445, 3*time.Second)
+	if err == nil {
+		defer conn.Close()
+		_, err = conn.Negotiate()
+	}
+	if err != nil {
+		return Finding{
+			ID:       "smb1-negotiate-failed",
+			Check:    check,
+			Severity: SeverityCritical,
+			Message:  fmt.Sprintf("Negotiation failed: %v", err),
+			Fix:      "Set 'server min protocol = NT1' in [global] with: sudo ps2smb fix",
+		}
+	}
+	return pass("smb1-negotiate", check)
+}
+
+func checkFirewall(cfg *config.Config) Finding {
+	const check = "Firewall allows SMB from the PS2 subnet"
+
+	fw, err := firewall.Detect()
+	if err != nil {
+		return Finding{
+			ID:       "firewall-unknown",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Could not inspect the firewall: %v", err),
+			Fix:      "Run with sudo, or inspect with: sudo ps2smb firewall status",
+		}
+	}
+	if fw == nil {
+		return pass("firewall", check)
+	}
+
+	subnet, err := network.PS2Subnet(cfg.BindInterface)
+	if err != nil {
+		return Finding{
+			ID:       "firewall-unknown",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Could not determine the PS2 subnet: %v", err),
+			Fix:      "Inspect with: sudo ps2smb firewall status --source <subnet>",
+		}
+	}
+	source := subnet.String()
+
+	allowed, err := fw.Allowed(source)
+	if err != nil {
+		return Finding{
+			ID:       "firewall-unknown",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Failed to inspect %s: %v", fw.Name(), err),
+			Fix:      "Inspect with: sudo ps2smb firewall status",
+		}
+	}
+	if firewall.AllAllowed(allowed) {
+		return pass("firewall", check)
+	}
+
+	var blocked []string
+	for _, p := range firewall.SMBPorts {
+		if !allowed[p] {
+			blocked = append(blocked, p.String())
+		}
+	}
+	return Finding{
+		ID:       "firewall-blocked",
+		Check:    check,
+		Severity: SeverityCritical,
+		Message:  fmt.Sprintf("%s does not allow %s from %s", fw.Name(), strings.Join(blocked, ", "), source),
+		Fix:      "Open with: sudo ps2smb firewall open",
+		Remedy: &Remedy{
+			ID:          "firewall-open",
+			Description: fmt.Sprintf("Allow SMB from %s through %s", source, fw.Name()),
+			Apply: func() error {
+				return fw.Open(source)
+			},
+		},
+	}
+}
@@ -0,0 +1,51 @@
+package health
+
+import (
+	"fmt"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/library"
+)
+
+// checkPlacement reports DVD images in CD/ and vice versa, which OPL either
+// hides or fails to boot
+func checkPlacement(cfg *config.Config) []Finding {
+	const check = "Games in the correct DVD/CD folder"
+
+	cat, err := library.Scan(cfg.GamesPath)
+	if err != nil {
+		// Missing directories are already reported by checkGamesDirs
+		return nil
+	}
+
+	misplaced := cat.Misplaced()
+	if len(misplaced) == 0 {
+		return []Finding{pass("games-placement", check)}
+	}
+
+	remedy := &Remedy{
+		ID:          "sort-games",
+		Description: fmt.Sprintf("Move %d misplaced image(s) into the right folder", len(misplaced)),
+		Apply: func() error {
+			cat, err := library.Scan(cfg.GamesPath)
+			if err != nil {
+				return err
+			}
+			_, err = library.ApplyMoves(cat.Root, library.PlanSort(cat))
+			return err
+		},
+	}
+
+	var findings []Finding
+	for _, g := range misplaced {
+		findings = append(findings, Finding{
+			ID:       "game-misplaced",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%s is a %s image (%s)", g.RelPath(), g.Detected, g.DetectedBy),
+			Fix:      "Move with: sudo ps2smb games sort",
+			Remedy:   remedy,
+		})
+	}
+	return findings
+}
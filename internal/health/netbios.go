@@ -0,0 +1,164 @@
+package health
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/netbios"
+	"github.com/matheusc457/ps2smb/internal/network"
+	"github.com/matheusc457/ps2smb/internal/samba"
+)
+
+// nameQueryTimeout is how long to wait for nmbd to answer a broadcast
+const nameQueryTimeout = 2 * time.Second
+
+// checkNetBIOS verifies the name OPL can use instead of the IP address:
+// a valid name, a running nmbd and an answer to a broadcast name query
+func checkNetBIOS(cfg *config.Config) []Finding {
+	id, err := netbios.ServerIdentity()
+	if err != nil {
+		return []Finding{{
+			ID:       "netbios-name-unknown",
+			Check:    "NetBIOS name valid",
+			Severity: SeverityWarning,
+			Message:  err.Error(),
+		}}
+	}
+
+	findings := []Finding{checkNetBIOSName(id)}
+
+	nmbd := checkNmbd()
+	findings = append(findings, nmbd)
+	if nmbd.OK() && findings[0].OK() {
+		findings = append(findings, checkNameQuery(cfg, id.Name))
+	}
+	return findings
+}
+
+func checkNetBIOSName(id *netbios.Identity) Finding {
+	check := fmt.Sprintf("NetBIOS name %s valid", id.Name)
+
+	err := netbios.ValidateName(id.Name)
+	if err == nil {
+		return pass("netbios-name", check)
+	}
+
+	f := Finding{
+		ID:       "netbios-name-invalid",
+		Check:    check,
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("%v; OPL cannot reach the server by name", err),
+	}
+	if fixed := netbios.Sanitize(id.Name); netbios.ValidateName(fixed) == nil {
+		f.Fix = fmt.Sprintf("Set a valid name with: sudo ps2smb netbios set --name %s", fixed)
+		f.Remedy = &Remedy{
+			ID:          "set-netbios-name",
+			Description: fmt.Sprintf("Set 'netbios name = %s' in [global] and restart Samba", fixed),
+			Apply: func() error {
+				if err := samba.BackupConfig(); err != nil {
+					return err
+				}
+				if err := samba.SetIdentity(fixed, ""); err != nil {
+					return err
+				}
+				if err := samba.RestartSamba(); err != nil {
+					return err
+				}
+				return samba.RestartNmbd()
+			},
+		}
+	}
+	return f
+}
+
+func checkNmbd() Finding {
+	const check = "NetBIOS name service (nmbd) running"
+	service := samba.GetNmbdServiceName()
+
+	if !samba.IsNmbdInstalled() {
+		return Finding{
+			ID:       "nmbd-not-installed",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  "nmbd was not found in PATH; OPL must use the IP address",
+			Fix:      "Install the Samba package that provides nmbd",
+		}
+	}
+	if !samba.IsNmbdRunning() {
+		return Finding{
+			ID:       "nmbd-not-running",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  "The server name will not resolve; OPL must use the IP address",
+			Fix:      fmt.Sprintf("Enable with: sudo systemctl enable --now %s", service),
+			Remedy: &Remedy{
+				ID:          "enable-nmbd",
+				Description: fmt.Sprintf("Enable and start the %s service", service),
+				Apply:       samba.EnableNmbd,
+			},
+		}
+	}
+	if !samba.IsNmbdEnabled() {
+		return Finding{
+			ID:       "nmbd-not-enabled",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%s is running but will not start after a reboot", service),
+			Fix:      fmt.Sprintf("Enable with: sudo systemctl enable %s", service),
+			Remedy: &Remedy{
+				ID:          "enable-nmbd",
+				Description: fmt.Sprintf("Enable the %s service on boot", service),
+				Apply:       samba.EnableNmbd,
+			},
+		}
+	}
+	return pass("nmbd-running", check)
+}
+
+// checkNameQuery broadcasts a name query on the PS2 subnet, as OPL does,
+// and checks the answer points at an address the PS2 can reach
+func checkNameQuery(cfg *config.Config, name string) Finding {
+	check := fmt.Sprintf("Name %s answers on the LAN", name)
+
+	subnet, err := network.PS2Subnet(cfg.BindInterface)
+	if err != nil {
+		return Finding{
+			ID:       "netbios-query-skipped",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Could not determine the PS2 subnet: %v", err),
+		}
+	}
+
+	answers, err := netbios.Query(name, netbios.SuffixServer, subnet.Broadcast, true, nameQueryTimeout)
+	if err != nil {
+		return Finding{
+			ID:       "netbios-query-failed",
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Broadcast query on %s failed: %v", subnet.Broadcast, err),
+			Fix:      "Check that nmbd listens on this interface and UDP 137 is open: sudo ps2smb firewall status",
+		}
+	}
+
+	var seen []string
+	for _, a := range answers {
+		for _, ip := range a.Addresses {
+			if subnet.Contains(ip) {
+				f := pass("netbios-query", check)
+				f.Message = fmt.Sprintf("Resolves to %s", ip)
+				return f
+			}
+			seen = append(seen, ip.String())
+		}
+	}
+	return Finding{
+		ID:       "netbios-query-wrong-address",
+		Check:    check,
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("Resolves to %s, outside the PS2 subnet %s", strings.Join(seen, ", "), subnet),
+		Fix:      "Restrict nmbd to the PS2 interface with: sudo ps2smb init --interface <iface>",
+	}
+}
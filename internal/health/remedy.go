@@ -0,0 +1,41 @@
+package health
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/samba"
+)
+
+func mkdirRemedy(path string) *Remedy {
+	return &Remedy{
+		ID:          "mkdir:" + path,
+		Description: fmt.Sprintf("Create %s", path),
+		Apply: func() error {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %v", path, err)
+			}
+			return nil
+		},
+	}
+}
+
+func rewriteShareRemedy(cfg *config.Config) *Remedy {
+	return &Remedy{
+		ID:          "rewrite-share",
+		Description: fmt.Sprintf("Rewrite the [%s] share from the saved configuration and restart Samba", cfg.ShareName),
+		Apply: func() error {
+			if err := samba.BackupConfig(); err != nil {
+				return err
+			}
+			if err := samba.AddPS2Share(cfg.GamesPath, cfg.UseGuest); err != nil {
+				return err
+			}
+			if err := samba.SetHostsAllow(cfg.HostsAllow); err != nil {
+				return err
+			}
+			return samba.RestartSamba()
+		},
+	}
+}
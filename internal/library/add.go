@@ -0,0 +1,335 @@
+package library
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/matheusc457/ps2smb/internal/directio"
+)
+
+// partSuffix marks an unfinished copy that a later add can resume
+const partSuffix = ".part"
+
+// copyBufferSize is large enough to keep disks streaming
+const copyBufferSize = 1 << 20
+
+// AddOptions tunes how an image is imported
+type AddOptions struct {
+	// Progress is called as bytes are copied (phase "copy") and read back
+	// for verification (phase "verify")
+	Progress func(phase string, done, total int64)
+	// UID and GID own the imported file; -1 inherits from the media folder
+	UID, GID int
+}
+
+// AddResult describes an imported image
+type AddResult struct {
+	Game    Game   `json:"game"`
+	Dest    string `json:"dest"`
+	SHA1    string `json:"sha1"`
+	Resumed int64  `json:"resumed_bytes,omitempty"`
+	// Converted is the track mode of a BIN/CUE source written out as ISO
+	Converted string `json:"converted_from,omitempty"`
+}
+
+// Add imports an image into the catalog: it reads the game ID, refuses
+// duplicates, picks the CD or DVD folder, names it in OPL format, copies it
+// with a resumable ".part" file, verifies the copy and makes it readable
+// by the share user. BIN/CUE images are converted to ISO on the way
+func Add(cat *Catalog, src string, opts AddOptions) (*AddResult, error) {
+	raw := IsRawImage(src)
+	var g Game
+	if raw {
+		g = ScanRaw(src)
+	} else {
+		g = ScanFile(src, "")
+	}
+	return addImage(cat, g, imageExt(src), func() (source, error) { return openSource(src, raw) }, opts)
+}
+
+// addImage imports a scanned game whose data open returns
+func addImage(cat *Catalog, g Game, ext string, open func() (source, error), opts AddOptions) (*AddResult, error) {
+	name := filepath.Base(g.Path)
+	if g.Error != "" {
+		return nil, fmt.Errorf("%s: %s", name, g.Error)
+	}
+
+	for _, existing := range cat.Games {
+		if existing.ID == g.ID {
+			return nil, fmt.Errorf("%s is already in the library as %s", g.ID, existing.RelPath())
+		}
+	}
+
+	applyTitle(&g, cat.titles)
+	title := NormalizeTitle(g.DisplayTitle())
+	if title == "" {
+		title = NormalizeTitle(g.VolumeID)
+	}
+	if title == "" {
+		title = g.ID
+	}
+	g.Media = g.Detected
+	g.File = OPLFileName(g.ID, title, ext)
+	g.Title = title
+
+	dir := mediaDir(cat.Root, g.Media)
+	if err := mkdirLike(dir, cat.Root); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+	dest := filepath.Join(dir, g.File)
+	if _, err := os.Lstat(dest); err == nil {
+		return nil, fmt.Errorf("%s already exists", g.RelPath())
+	}
+
+	in, err := open()
+	if err != nil {
+		return nil, err
+	}
+	sum, resumed, err := copyResumable(in, dest+partSuffix, phaseProgress(opts.Progress, "copy"))
+	in.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	verify, err := hashFile(dest+partSuffix, phaseProgress(opts.Progress, "verify"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify copy: %v", err)
+	}
+	if verify != sum {
+		os.Remove(dest + partSuffix)
+		return nil, fmt.Errorf("verification failed: copy of %s does not match the source", name)
+	}
+
+	if err := setOwnership(dest+partSuffix, dir, opts.UID, opts.GID); err != nil {
+		return nil, err
+	}
+	if err := renameNoReplace(dest+partSuffix, dest); err != nil {
+		return nil, fmt.Errorf("failed to move copy into place: %v", err)
+	}
+
+	g.Path = dest
+	cat.Games = append(cat.Games, g)
+	res := &AddResult{Game: g, Dest: dest, SHA1: sum, Resumed: resumed}
+	if r, ok := in.(*RawImage); ok {
+		res.Converted = r.Mode
+	}
+	return res, nil
+}
+
+// source is an image being imported: a plain file or a converted BIN
+type source interface {
+	io.ReaderAt
+	io.Closer
+	Size() int64
+}
+
+// fileSource adapts an ISO file to source
+type fileSource struct {
+	*os.File
+	size int64
+}
+
+func (f *fileSource) Size() int64 {
+	return f.size
+}
+
+func openSource(path string, raw bool) (source, error) {
+	if raw {
+		return OpenRaw(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileSource{File: f, size: info.Size()}, nil
+}
+
+// copyResumable copies in to part, continuing after any bytes a previous
+// interrupted run left behind. Those bytes are compared with the same range
+// of the source first, and the copy restarts if they differ, so a stale
+// .part file is never spliced in. The SHA-1 is always computed from the
+// source
+func copyResumable(in source, part string, progress func(done, total int64)) (string, int64, error) {
+	total := in.Size()
+
+	out, err := os.OpenFile(part, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open %s: %v", part, err)
+	}
+	defer out.Close()
+
+	h := sha1.New()
+	pw := &progressWriter{total: total, fn: progress}
+	buf := make([]byte, copyBufferSize)
+
+	// Resume only from whole buffers; a torn final write is copied again
+	resumed := int64(0)
+	if st, err := out.Stat(); err == nil && st.Size() <= total {
+		resumed = st.Size() / copyBufferSize * copyBufferSize
+	}
+	if resumed > 0 {
+		same, err := matchPrefix(in, out, resumed, io.MultiWriter(h, pw))
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to read partial copy: %v", err)
+		}
+		if !same {
+			resumed = 0
+			h.Reset()
+			pw.done = 0
+		}
+	}
+	if err := out.Truncate(resumed); err != nil {
+		return "", 0, err
+	}
+	if _, err := out.Seek(resumed, io.SeekStart); err != nil {
+		return "", 0, err
+	}
+
+	rest := io.NewSectionReader(in, resumed, total-resumed)
+	if _, err := io.CopyBuffer(io.MultiWriter(out, h, pw), rest, buf); err != nil {
+		var bad *SectorError
+		if errors.As(err, &bad) {
+			out.Close()
+			os.Remove(part)
+			return "", 0, fmt.Errorf("cannot convert: %v", err)
+		}
+		return "", 0, fmt.Errorf("copy interrupted (run again to resume): %v", err)
+	}
+	if err := out.Sync(); err != nil {
+		return "", 0, fmt.Errorf("failed to flush copy: %v", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), resumed, nil
+}
+
+// matchPrefix compares the first n bytes of a partial copy with the
+// source, writing the source bytes to w as they are checked
+func matchPrefix(in source, part io.ReaderAt, n int64, w io.Writer) (bool, error) {
+	want := make([]byte, copyBufferSize)
+	got := make([]byte, copyBufferSize)
+	for off := int64(0); off < n; off += copyBufferSize {
+		size := int(min(copyBufferSize, n-off))
+		if _, err := in.ReadAt(want[:size], off); err != nil && err != io.EOF {
+			return false, err
+		}
+		if _, err := part.ReadAt(got[:size], off); err != nil && err != io.EOF {
+			return false, err
+		}
+		if !bytes.Equal(want[:size], got[:size]) {
+			return false, nil
+		}
+		w.Write(want[:size])
+	}
+	return true, nil
+}
+
+// hashFile returns the SHA-1 of a file
+func hashFile(path string, progress func(done, total int64)) (string, error) {
+	f, size, err := openDirect(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return hashReader(f, size, progress)
+}
+
+// readerAtCloser is a file opened by openDirect or openFile
+type readerAtCloser interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// openDirect opens a file for reading past the page cache, so a check
+// right after writing reads what reached the disk rather than the pages
+// just written. File systems without direct I/O fall back to normal reads
+func openDirect(path string) (readerAtCloser, int64, error) {
+	if d, err := directio.Open(path); err == nil {
+		return d, d.Size(), nil
+	}
+	return openFile(path)
+}
+
+// openFile opens a file for normal, cached reads
+func openFile(path string) (readerAtCloser, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+// hashReader returns the SHA-1 of the first size bytes of r
+func hashReader(r io.ReaderAt, size int64, progress func(done, total int64)) (string, error) {
+	h := sha1.New()
+	w := io.MultiWriter(h, &progressWriter{total: size, fn: progress})
+	if _, err := io.CopyBuffer(w, io.NewSectionReader(r, 0, size), make([]byte, copyBufferSize)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// setOwnership makes an imported file world-readable and gives it the
+// requested owner, or the owner of the media folder
+func setOwnership(path, dir string, uid, gid int) error {
+	if err := os.Chmod(path, 0644); err != nil {
+		return fmt.Errorf("failed to set permissions: %v", err)
+	}
+
+	if uid < 0 || gid < 0 {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+		st, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return nil
+		}
+		if uid < 0 {
+			uid = int(st.Uid)
+		}
+		if gid < 0 {
+			gid = int(st.Gid)
+		}
+	}
+	if err := os.Chown(path, uid, gid); err != nil && !os.IsPermission(err) {
+		return fmt.Errorf("failed to set ownership: %v", err)
+	}
+	return nil
+}
+
+type progressWriter struct {
+	done, total int64
+	fn          func(done, total int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	if p.fn != nil {
+		p.fn(p.done, p.total)
+	}
+	return len(b), nil
+}
+
+// phaseProgress adapts a phased progress callback to a single phase
+func phaseProgress(fn func(phase string, done, total int64), phase string) func(done, total int64) {
+	if fn == nil {
+		return nil
+	}
+	return func(done, total int64) { fn(phase, done, total) }
+}
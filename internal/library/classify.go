@@ -0,0 +1,73 @@
+package library
+
+import "fmt"
+
+// MaxCDSectors is the capacity of an 80-minute CD-ROM in 2048-byte sectors;
+// anything larger can only be a DVD
+const MaxCDSectors = 360000
+
+// Classify decides whether an image is a CD or a DVD. PS2 DVDs always carry
+// a UDF bridge while CDs are plain ISO9660, so the structure decides first
+// and the volume size settles images that were rebuilt without UDF
+func Classify(v *Volume, fileSize int64) (Media, string) {
+	if v.UDF {
+		return MediaDVD, "UDF bridge present"
+	}
+
+	size := max(v.Size(), fileSize)
+	if size > MaxCDSectors*SectorSize {
+		return MediaDVD, fmt.Sprintf("%d MiB is larger than a CD", size>>20)
+	}
+	return MediaCD, "ISO9660 only and fits on a CD"
+}
+
+// Move relocates an image into the folder matching its media
+type Move struct {
+	File string `json:"file"`
+	From Media  `json:"from"`
+	To   Media  `json:"to"`
+	Why  string `json:"why"`
+	Skip string `json:"skip,omitempty"`
+}
+
+// PlanSort lists the moves needed to put every misplaced image in the
+// right folder. Moves onto an existing file are skipped
+func PlanSort(cat *Catalog) []Move {
+	taken := make(map[string]bool)
+	for _, g := range cat.Games {
+		taken[g.RelPath()] = true
+	}
+
+	var moves []Move
+	for _, g := range cat.Misplaced() {
+		m := Move{File: g.File, From: g.Media, To: g.Detected, Why: g.DetectedBy}
+		target := string(m.To) + "/" + m.File
+		if taken[target] {
+			m.Skip = fmt.Sprintf("%s already exists", target)
+		}
+		taken[target] = true
+		moves = append(moves, m)
+	}
+	return moves
+}
+
+// ApplyMoves performs every move that is not skipped, creating the target
+// folders as needed. Like renames, the batch is all-or-nothing
+func ApplyMoves(root string, moves []Move) (int, error) {
+	var pairs [][2]string
+	for _, m := range moves {
+		if m.Skip != "" {
+			continue
+		}
+		dir := mediaDir(root, m.To)
+		if err := mkdirLike(dir, mediaDir(root, m.From)); err != nil {
+			return 0, fmt.Errorf("failed to create %s: %v", dir, err)
+		}
+		pairs = append(pairs, [2]string{mediaPath(root, m.From, m.File), mediaPath(root, m.To, m.File)})
+	}
+
+	if err := renameAll(pairs); err != nil {
+		return 0, err
+	}
+	return len(pairs), nil
+}
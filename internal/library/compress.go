@@ -0,0 +1,141 @@
+package library
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// ConvertOptions tunes CompressImage and DecompressImage
+type ConvertOptions struct {
+	// Progress is called while writing (phase "compress" or "decompress")
+	// and while reading the result back (phase "verify")
+	Progress func(phase string, done, total int64)
+	// Keep leaves the original image in place
+	Keep bool
+}
+
+// ConvertResult describes an image converted between ISO and ZSO
+type ConvertResult struct {
+	Source  string `json:"source"`
+	Dest    string `json:"dest"`
+	SHA1    string `json:"sha1"`
+	OldSize int64  `json:"old_size"`
+	NewSize int64  `json:"new_size"`
+}
+
+// CompressImage writes a ZSO next to an ISO, checks that decompressing it
+// reproduces the ISO's SHA-1 and then removes the ISO
+func CompressImage(g Game, opts ConvertOptions) (*ConvertResult, error) {
+	if IsZSO(g.File) {
+		return nil, fmt.Errorf("%s is already compressed", g.File)
+	}
+
+	in, err := openSource(g.Path, false)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	dest := swapExt(g.Path, ".zso")
+	part := dest + partSuffix
+	if _, err := os.Lstat(dest); err == nil {
+		return nil, fmt.Errorf("%s already exists", filepath.Base(dest))
+	}
+
+	sum, err := WriteZSO(part, in, in.Size(), phaseProgress(opts.Progress, "compress"))
+	if err != nil {
+		os.Remove(part)
+		return nil, fmt.Errorf("failed to compress: %v", err)
+	}
+
+	z, err := openZSO(part, openDirect)
+	if err != nil {
+		os.Remove(part)
+		return nil, fmt.Errorf("failed to verify: %v", err)
+	}
+	verify, err := hashReader(z, z.Size(), phaseProgress(opts.Progress, "verify"))
+	z.Close()
+	if err != nil || verify != sum {
+		os.Remove(part)
+		if err == nil {
+			err = fmt.Errorf("decompressed data does not match the original")
+		}
+		return nil, fmt.Errorf("failed to verify: %v", err)
+	}
+
+	return finishConvert(g.Path, part, dest, sum, opts.Keep)
+}
+
+// DecompressImage writes the ISO inside a ZSO next to it, verifies the
+// written file and then removes the ZSO
+func DecompressImage(g Game, opts ConvertOptions) (*ConvertResult, error) {
+	if !IsZSO(g.File) {
+		return nil, fmt.Errorf("%s is not a ZSO image", g.File)
+	}
+
+	z, err := OpenZSO(g.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	dest := swapExt(g.Path, ".iso")
+	part := dest + partSuffix
+	if _, err := os.Lstat(dest); err == nil {
+		z.Close()
+		return nil, fmt.Errorf("%s already exists", filepath.Base(dest))
+	}
+
+	sum, _, err := copyResumable(z, part, phaseProgress(opts.Progress, "decompress"))
+	z.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	verify, err := hashFile(part, phaseProgress(opts.Progress, "verify"))
+	if err != nil || verify != sum {
+		os.Remove(part)
+		if err == nil {
+			err = fmt.Errorf("written ISO does not match the decompressed data")
+		}
+		return nil, fmt.Errorf("failed to verify: %v", err)
+	}
+
+	return finishConvert(g.Path, part, dest, sum, opts.Keep)
+}
+
+// finishConvert gives the verified copy the original's permissions, moves
+// it into place and removes the original unless asked to keep it
+func finishConvert(src, part, dest, sum string, keep bool) (*ConvertResult, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(part, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to set permissions: %v", err)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		os.Chown(part, int(st.Uid), int(st.Gid))
+	}
+
+	written, err := os.Stat(part)
+	if err != nil {
+		return nil, err
+	}
+	if err := renameNoReplace(part, dest); err != nil {
+		return nil, fmt.Errorf("failed to move into place: %v", err)
+	}
+
+	if !keep {
+		if err := os.Remove(src); err != nil {
+			return nil, fmt.Errorf("converted, but failed to remove %s: %v", filepath.Base(src), err)
+		}
+	}
+	return &ConvertResult{Source: src, Dest: dest, SHA1: sum, OldSize: info.Size(), NewSize: written.Size()}, nil
+}
+
+func swapExt(path, ext string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ext
+}
@@ -0,0 +1,375 @@
+package library
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// RawSectorSize is the size of a raw CD sector as stored in BIN images:
+// sync, header, user data and error correction
+const RawSectorSize = 2352
+
+// Track modes a data track can be converted from
+const (
+	ModeCooked = "MODE1/2048"
+	ModeMode1  = "MODE1/2352"
+	ModeMode2  = "MODE2/2352"
+)
+
+// Offsets inside a raw sector (ECMA-130, CD-ROM XA)
+const (
+	rawModeOffset    = 15
+	rawSubmodeOffset = 18
+	rawMode1Data     = 16
+	rawMode2Data     = 24
+	rawForm2Size     = 2324
+	submodeForm2     = 0x20
+	framesPerSecond  = 75
+	rawReadSectors   = 512
+)
+
+// rawSync opens every raw data sector
+var rawSync = []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}
+
+// CueTrack is one TRACK entry of a CUE sheet
+type CueTrack struct {
+	Number int
+	Mode   string
+	File   string
+	// Start is the INDEX 01 position in sectors from the start of File
+	Start int64
+}
+
+// CueSheet is a parsed CUE sheet. File names are resolved relative to the
+// sheet's directory
+type CueSheet struct {
+	Path   string
+	Files  []string
+	Tracks []CueTrack
+}
+
+// IsRawImage reports whether a file name looks like a BIN/CUE image that
+// must be converted before OPL can load it
+func IsRawImage(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".cue" || ext == ".bin"
+}
+
+// ReadCue parses the CUE sheet at path
+func ReadCue(path string) (*CueSheet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheet := &CueSheet{Path: path}
+	dir := filepath.Dir(path)
+	var file string
+	var track *CueTrack
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := cueFields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "FILE":
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("line %d: FILE needs a name and a type", line)
+			}
+			if typ := strings.ToUpper(fields[2]); typ != "BINARY" {
+				return nil, fmt.Errorf("line %d: unsupported file type %s", line, typ)
+			}
+			file = fields[1]
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			sheet.Files = append(sheet.Files, file)
+		case "TRACK":
+			if len(fields) < 3 || file == "" {
+				return nil, fmt.Errorf("line %d: TRACK needs a number, a mode and a preceding FILE", line)
+			}
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid track number %q", line, fields[1])
+			}
+			sheet.Tracks = append(sheet.Tracks, CueTrack{Number: n, Mode: strings.ToUpper(fields[2]), File: file, Start: -1})
+			track = &sheet.Tracks[len(sheet.Tracks)-1]
+		case "INDEX":
+			if len(fields) < 3 || track == nil {
+				return nil, fmt.Errorf("line %d: INDEX outside a TRACK", line)
+			}
+			if fields[1] != "01" && fields[1] != "1" {
+				continue
+			}
+			pos, err := parseMSF(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			track.Start = pos
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(sheet.Tracks) == 0 {
+		return nil, fmt.Errorf("%s has no tracks", filepath.Base(path))
+	}
+	for _, t := range sheet.Tracks {
+		if t.Start < 0 {
+			return nil, fmt.Errorf("track %d has no INDEX 01", t.Number)
+		}
+	}
+	return sheet, nil
+}
+
+// cueFields splits a CUE line on spaces, keeping quoted names together
+func cueFields(line string) []string {
+	var fields []string
+	line = strings.TrimSpace(line)
+	for line != "" {
+		if line[0] == '"' {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				fields = append(fields, line[1:])
+				break
+			}
+			fields = append(fields, line[1:end+1])
+			line = strings.TrimSpace(line[end+2:])
+			continue
+		}
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			fields = append(fields, line)
+			break
+		}
+		fields = append(fields, line[:end])
+		line = strings.TrimSpace(line[end:])
+	}
+	return fields
+}
+
+// parseMSF converts an mm:ss:ff position to a sector count
+func parseMSF(s string) (int64, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid position %q", s)
+	}
+	var v [3]int64
+	for i, p := range parts {
+		n, err := strconv.ParseInt(p, 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid position %q", s)
+		}
+		v[i] = n
+	}
+	if v[1] >= 60 || v[2] >= framesPerSecond {
+		return 0, fmt.Errorf("invalid position %q", s)
+	}
+	return (v[0]*60+v[1])*framesPerSecond + v[2], nil
+}
+
+// SectorError reports a raw sector that cannot be converted. Unlike a read
+// error, retrying will not help
+type SectorError struct {
+	Sector int64
+	Reason string
+}
+
+func (e *SectorError) Error() string {
+	return fmt.Sprintf("sector %d %s", e.Sector, e.Reason)
+}
+
+// RawImage presents the data track of a BIN image as the 2048-byte
+// sectors of an ISO. It implements io.ReaderAt
+type RawImage struct {
+	f          *os.File
+	Mode       string
+	start      int64
+	sectorSize int64
+	sectors    int64
+}
+
+// OpenRaw opens a BIN/CUE image given either file. A BIN without a CUE
+// sheet next to it is accepted when its sectors are raw and
+// self-describing. Layouts with more than one track are refused: an ISO
+// holds a single data track, and OPL cannot play CD audio anyway
+func OpenRaw(path string) (*RawImage, error) {
+	var track CueTrack
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".cue":
+		sheet, err := ReadCue(path)
+		if err != nil {
+			return nil, err
+		}
+		if track, err = sheet.dataTrack(); err != nil {
+			return nil, err
+		}
+	default:
+		cue := strings.TrimSuffix(path, filepath.Ext(path)) + ".cue"
+		if _, err := os.Stat(cue); err == nil {
+			return OpenRaw(cue)
+		}
+		mode, err := sniffRawMode(path)
+		if err != nil {
+			return nil, err
+		}
+		track = CueTrack{Number: 1, Mode: mode, File: path}
+	}
+
+	r := &RawImage{Mode: track.Mode}
+	switch track.Mode {
+	case ModeMode1, ModeMode2:
+		r.sectorSize = RawSectorSize
+	case ModeCooked:
+		r.sectorSize = SectorSize
+	default:
+		return nil, fmt.Errorf("unsupported track mode %s", track.Mode)
+	}
+
+	f, err := os.Open(track.File)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.Size()%r.sectorSize != 0 {
+		f.Close()
+		return nil, fmt.Errorf("%s is not a whole number of %d-byte sectors", filepath.Base(track.File), r.sectorSize)
+	}
+
+	r.f = f
+	r.start = track.Start
+	r.sectors = info.Size()/r.sectorSize - track.Start
+	if r.sectors <= 0 {
+		f.Close()
+		return nil, fmt.Errorf("track %d starts past the end of %s", track.Number, filepath.Base(track.File))
+	}
+	return r, nil
+}
+
+// dataTrack returns the only track of a single-track data disc
+func (c *CueSheet) dataTrack() (CueTrack, error) {
+	if len(c.Tracks) > 1 || len(c.Files) > 1 {
+		var modes []string
+		for _, t := range c.Tracks {
+			modes = append(modes, fmt.Sprintf("%d:%s", t.Number, t.Mode))
+		}
+		return CueTrack{}, fmt.Errorf("multi-track layout (%s) cannot be stored as a single ISO", strings.Join(modes, ", "))
+	}
+	t := c.Tracks[0]
+	if t.Mode == "AUDIO" {
+		return CueTrack{}, fmt.Errorf("track %d is an audio track", t.Number)
+	}
+	return t, nil
+}
+
+// sniffRawMode reads the mode byte of the primary volume descriptor sector
+// of a BIN without a CUE sheet
+func sniffRawMode(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, RawSectorSize)
+	if _, err := f.ReadAt(buf, descriptorStart*RawSectorSize); err != nil {
+		return "", fmt.Errorf("no CUE sheet and not a raw CD image")
+	}
+	if !bytes.Equal(buf[:len(rawSync)], rawSync) {
+		return "", fmt.Errorf("no CUE sheet and not a raw CD image")
+	}
+	switch buf[rawModeOffset] {
+	case 1:
+		return ModeMode1, nil
+	case 2:
+		return ModeMode2, nil
+	}
+	return "", fmt.Errorf("unknown sector mode %d", buf[rawModeOffset])
+}
+
+// Size returns the size of the converted ISO in bytes
+func (r *RawImage) Size() int64 {
+	return r.sectors * SectorSize
+}
+
+// Close closes the underlying BIN file
+func (r *RawImage) Close() error {
+	return r.f.Close()
+}
+
+// ReadAt reads converted ISO bytes. Raw sectors are checked for sync and
+// mode; Mode 2 Form 2 sectors carry 2324 bytes that an ISO cannot hold, so
+// they are only accepted when blank, as in the post-gap
+func (r *RawImage) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+	n := 0
+	raw := make([]byte, rawReadSectors*r.sectorSize)
+	for n < len(p) {
+		pos := off + int64(n)
+		sector := pos / SectorSize
+		if sector >= r.sectors {
+			return n, io.EOF
+		}
+
+		last := (pos + int64(len(p)-n) - 1) / SectorSize
+		count := min(last-sector+1, r.sectors-sector, rawReadSectors)
+		buf := raw[:count*r.sectorSize]
+		if _, err := r.f.ReadAt(buf, (r.start+sector)*r.sectorSize); err != nil {
+			return n, fmt.Errorf("failed to read sector %d: %v", sector, err)
+		}
+
+		for i := int64(0); i < count && n < len(p); i++ {
+			data, err := r.userData(buf[i*r.sectorSize:(i+1)*r.sectorSize], sector+i)
+			if err != nil {
+				return n, err
+			}
+			skip := int((off + int64(n)) % SectorSize)
+			n += copy(p[n:], data[skip:])
+		}
+	}
+	return n, nil
+}
+
+// userData extracts the 2048 bytes of user data from one sector
+func (r *RawImage) userData(s []byte, sector int64) ([]byte, error) {
+	if r.sectorSize == SectorSize {
+		return s, nil
+	}
+	if !bytes.Equal(s[:len(rawSync)], rawSync) {
+		return nil, &SectorError{sector, "has no sync pattern"}
+	}
+
+	switch s[rawModeOffset] {
+	case 0:
+		return make([]byte, SectorSize), nil
+	case 1:
+		return s[rawMode1Data : rawMode1Data+SectorSize], nil
+	case 2:
+		if s[rawSubmodeOffset]&submodeForm2 != 0 {
+			data := s[rawMode2Data : rawMode2Data+rawForm2Size]
+			if bytes.Count(data, []byte{0}) != len(data) {
+				return nil, &SectorError{sector, "is Mode 2 Form 2 and cannot be stored in an ISO"}
+			}
+			return make([]byte, SectorSize), nil
+		}
+		return s[rawMode2Data : rawMode2Data+SectorSize], nil
+	}
+	return nil, &SectorError{sector, fmt.Sprintf("has unknown mode %d", s[rawModeOffset])}
+}
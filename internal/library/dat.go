@@ -0,0 +1,166 @@
+package library
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// DAT is a Logiqx XML datafile, the format Redump and No-Intro publish
+type DAT struct {
+	Name    string    `xml:"header>name"`
+	Version string    `xml:"header>version"`
+	Games   []DATGame `xml:"game"`
+
+	bySHA1 map[string]datEntry
+	byKey  map[string][]*DATGame
+}
+
+// DATGame is one game of a DAT. Serial is only present in some DATs and
+// may list several serials separated by commas
+type DATGame struct {
+	Name   string   `xml:"name,attr"`
+	Serial string   `xml:"serial"`
+	Roms   []DATRom `xml:"rom"`
+}
+
+// DATRom is one file of a game with its expected checksums
+type DATRom struct {
+	Name string `xml:"name,attr" json:"name"`
+	Size int64  `xml:"size,attr" json:"size"`
+	CRC  string `xml:"crc,attr" json:"crc32"`
+	MD5  string `xml:"md5,attr" json:"md5"`
+	SHA1 string `xml:"sha1,attr" json:"sha1"`
+}
+
+type datEntry struct {
+	game *DATGame
+	rom  *DATRom
+}
+
+// ReadDAT parses a Logiqx XML DAT file and indexes it for lookups
+func ReadDAT(path string) (*DAT, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dat := &DAT{}
+	if err := xml.NewDecoder(f).Decode(dat); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if len(dat.Games) == 0 {
+		return nil, fmt.Errorf("%s contains no games", path)
+	}
+
+	dat.bySHA1 = make(map[string]datEntry)
+	dat.byKey = make(map[string][]*DATGame)
+	for i := range dat.Games {
+		g := &dat.Games[i]
+		for j := range g.Roms {
+			r := &g.Roms[j]
+			r.CRC = strings.ToLower(r.CRC)
+			r.MD5 = strings.ToLower(r.MD5)
+			r.SHA1 = strings.ToLower(r.SHA1)
+			if r.SHA1 != "" {
+				dat.bySHA1[r.SHA1] = datEntry{g, r}
+			}
+		}
+
+		if key := titleKey(g.Name); key != "" {
+			dat.byKey[key] = append(dat.byKey[key], g)
+		}
+		for _, serial := range strings.Split(g.Serial, ",") {
+			if key := serialKey(serial); key != "" {
+				dat.byKey[key] = append(dat.byKey[key], g)
+			}
+		}
+	}
+	return dat, nil
+}
+
+// Match returns the DAT entry whose checksums all equal h
+func (d *DAT) Match(h Hashes) (*DATGame, *DATRom) {
+	e, ok := d.bySHA1[h.SHA1]
+	if !ok || e.rom.Size != h.Size || (e.rom.CRC != "" && e.rom.CRC != h.CRC32) || (e.rom.MD5 != "" && e.rom.MD5 != h.MD5) {
+		return nil, nil
+	}
+	return e.game, e.rom
+}
+
+// Expected returns the DAT rom an image of size bytes should have matched:
+// one listed under the same serial or title, preferring a rom of the same
+// size. It returns nil when the DAT does not know the game. sure reports
+// whether the image must be that game: title keys ignore region and
+// revision tags, so a title match only counts when the size agrees too
+func (d *DAT) Expected(g Game, size int64) (game *DATGame, rom *DATRom, sure bool) {
+	var candidates []*DATGame
+	bySerial := false
+	if key := serialKey(g.ID); key != "" {
+		candidates = d.byKey[key]
+		bySerial = len(candidates) > 0
+	}
+	if key := titleKey(g.DisplayTitle()); len(candidates) == 0 && key != "" {
+		candidates = d.byKey[key]
+	}
+
+	var best *DATGame
+	var bestRom *DATRom
+	for _, c := range candidates {
+		for i := range c.Roms {
+			r := &c.Roms[i]
+			// CUE sheets describe a dump rather than hold it
+			if !IsImage(r.Name) && !strings.EqualFold(filepath.Ext(r.Name), ".bin") {
+				continue
+			}
+			if bestRom == nil || (r.Size == size && bestRom.Size != size) {
+				best, bestRom = c, r
+			}
+		}
+	}
+	if bestRom == nil {
+		return nil, nil, false
+	}
+	return best, bestRom, bySerial || bestRom.Size == size
+}
+
+// serialKey reduces "SLUS-20312" and "SLUS_203.12" to the same key
+func serialKey(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "serial:" + b.String()
+}
+
+// titleKey compares titles without region tags, punctuation or case:
+// "Okami (USA)" and "Okami" share a key
+func titleKey(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(NormalizeTitle(StripTitleTags(s))) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "title:" + b.String()
+}
+
+// StripTitleTags drops trailing "(USA)" or "[!]" style tags, as in DAT names
+func StripTitleTags(s string) string {
+	if i := strings.IndexAny(s, "(["); i > 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
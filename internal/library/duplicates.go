@@ -0,0 +1,351 @@
+package library
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DuplicateReport groups library images that hold the same game
+type DuplicateReport struct {
+	// Duplicates are sets of images with identical content
+	Duplicates []DuplicateSet `json:"duplicates"`
+	// Revisions are images with the same game ID but different content
+	Revisions []ImageGroup `json:"revisions"`
+	// Variants are the same title released under several game IDs,
+	// usually one per region
+	Variants []ImageGroup `json:"variants"`
+	// Reclaimable is the disk space removing every extra copy would free
+	Reclaimable int64 `json:"reclaimable"`
+	// Unreadable are images that could not be hashed
+	Unreadable []ImageRef `json:"unreadable,omitempty"`
+}
+
+// DuplicateSet is one image to keep and its identical copies
+type DuplicateSet struct {
+	ID          string          `json:"id,omitempty"`
+	Title       string          `json:"title"`
+	SHA1        string          `json:"sha1"`
+	Keep        string          `json:"keep"`
+	Copies      []DuplicateCopy `json:"copies"`
+	Reclaimable int64           `json:"reclaimable"`
+
+	keepPath string
+}
+
+// DuplicateCopy is an extra copy of a kept image
+type DuplicateCopy struct {
+	File string `json:"file"`
+	Size int64  `json:"size"`
+	// Linked copies are already hard links to the kept image and take no
+	// extra space
+	Linked bool `json:"linked,omitempty"`
+	// CanLink is false when the copy is stored in another format than
+	// the kept image (ISO and ZSO), so a hard link would change it
+	CanLink bool `json:"can_link"`
+
+	path       string
+	compressed bool
+}
+
+// ImageRef identifies an image in a revision or variant group
+type ImageRef struct {
+	File    string `json:"file"`
+	ID      string `json:"id,omitempty"`
+	Region  Region `json:"region,omitempty"`
+	Version string `json:"version,omitempty"`
+	Size    int64  `json:"size"`
+	SHA1    string `json:"sha1,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ImageGroup is a game found as several different images
+type ImageGroup struct {
+	ID     string     `json:"id,omitempty"`
+	Title  string     `json:"title"`
+	Images []ImageRef `json:"images"`
+}
+
+// FindDuplicates groups the catalog by game ID and by content hash. Only
+// images that share a game ID (or, without one, a size) with another image
+// can be identical, so only those are hashed
+func FindDuplicates(cat *Catalog, opts HashOptions) *DuplicateReport {
+	report := &DuplicateReport{}
+
+	var games []Game
+	for _, g := range cat.Games {
+		if g.Error == "" {
+			games = append(games, g)
+		}
+	}
+
+	groups := make(map[string][]int)
+	var keys []string
+	for i, g := range games {
+		key := "id:" + g.ID
+		if g.ID == "" {
+			key = fmt.Sprintf("size:%d", imageSize(g))
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	var toHash []Game
+	var index []int
+	for _, key := range keys {
+		if len(groups[key]) < 2 {
+			continue
+		}
+		for _, i := range groups[key] {
+			toHash = append(toHash, games[i])
+			index = append(index, i)
+		}
+	}
+	sums := make(map[int]string)
+	for j, h := range HashGames(toHash, opts) {
+		g := games[index[j]]
+		if h.Err != nil {
+			report.Unreadable = append(report.Unreadable, ImageRef{File: g.RelPath(), ID: g.ID, Size: g.Size, Error: h.Err.Error()})
+			continue
+		}
+		sums[index[j]] = h.Hashes.SHA1
+	}
+
+	for _, key := range keys {
+		members := groups[key]
+		if len(members) < 2 {
+			continue
+		}
+
+		bySum := make(map[string][]Game)
+		var order []string
+		for _, i := range members {
+			sum, ok := sums[i]
+			if !ok {
+				continue
+			}
+			if _, seen := bySum[sum]; !seen {
+				order = append(order, sum)
+			}
+			bySum[sum] = append(bySum[sum], games[i])
+		}
+
+		var distinct []ImageRef
+		for _, sum := range order {
+			copies := bySum[sum]
+			sort.SliceStable(copies, func(a, b int) bool { return keepRank(&copies[a]) < keepRank(&copies[b]) })
+			keep := copies[0]
+			distinct = append(distinct, imageRef(keep, sum))
+			if len(copies) > 1 {
+				set := duplicateSet(keep, copies[1:], sum)
+				report.Duplicates = append(report.Duplicates, set)
+				report.Reclaimable += set.Reclaimable
+			}
+		}
+		if strings.HasPrefix(key, "id:") && len(distinct) > 1 {
+			g := bySum[order[0]][0]
+			report.Revisions = append(report.Revisions, ImageGroup{ID: g.ID, Title: g.DisplayTitle(), Images: distinct})
+		}
+	}
+
+	report.Variants = findVariants(games)
+	return report
+}
+
+// keepRank orders copies by how suitable they are to keep: in the right
+// folder first, then with an OPL name, then by path
+func keepRank(g *Game) string {
+	rank := []byte("00")
+	if g.Detected != "" && g.Detected != g.Media {
+		rank[0] = '1'
+	}
+	if g.ID == "" || !strings.HasPrefix(g.File, g.ID+".") {
+		rank[1] = '1'
+	}
+	return string(rank) + g.RelPath()
+}
+
+func imageRef(g Game, sum string) ImageRef {
+	return ImageRef{File: g.RelPath(), ID: g.ID, Region: g.Region, Version: g.Version, Size: g.Size, SHA1: sum}
+}
+
+func duplicateSet(keep Game, copies []Game, sum string) DuplicateSet {
+	set := DuplicateSet{
+		ID:       keep.ID,
+		Title:    keep.DisplayTitle(),
+		SHA1:     sum,
+		Keep:     keep.RelPath(),
+		keepPath: keep.Path,
+	}
+
+	// Space is only freed once per inode, and never for the kept one
+	keepInfo, _ := os.Stat(keep.Path)
+	var counted []os.FileInfo
+	for _, g := range copies {
+		c := DuplicateCopy{
+			File:       g.RelPath(),
+			Size:       g.Size,
+			CanLink:    g.Compressed == keep.Compressed,
+			path:       g.Path,
+			compressed: g.Compressed,
+		}
+		info, err := os.Stat(g.Path)
+		if err == nil && keepInfo != nil && os.SameFile(info, keepInfo) {
+			c.Linked = true
+		} else if err == nil && !sameAsAny(info, counted) {
+			counted = append(counted, info)
+			set.Reclaimable += g.Size
+		}
+		set.Copies = append(set.Copies, c)
+	}
+	return set
+}
+
+func sameAsAny(info os.FileInfo, others []os.FileInfo) bool {
+	for _, o := range others {
+		if os.SameFile(info, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// findVariants groups identified games by title across game IDs
+func findVariants(games []Game) []ImageGroup {
+	byTitle := make(map[string][]Game)
+	var order []string
+	seen := make(map[string]bool)
+	for _, g := range games {
+		key := titleKey(g.DisplayTitle())
+		if g.ID == "" || key == "" || seen[g.ID] {
+			continue
+		}
+		seen[g.ID] = true
+		if _, ok := byTitle[key]; !ok {
+			order = append(order, key)
+		}
+		byTitle[key] = append(byTitle[key], g)
+	}
+
+	var variants []ImageGroup
+	for _, key := range order {
+		ids := byTitle[key]
+		if len(ids) < 2 {
+			continue
+		}
+		group := ImageGroup{Title: StripTitleTags(ids[0].DisplayTitle())}
+		for _, g := range ids {
+			group.Images = append(group.Images, imageRef(g, ""))
+		}
+		variants = append(variants, group)
+	}
+	return variants
+}
+
+// ResolveDuplicates deletes the extra copies of each set or, with link,
+// replaces them by hard links to the kept image. Copies that are already
+// linked, or cannot be linked, are left alone when linking. It stops at
+// the first failure and returns the copies handled and the bytes freed
+func ResolveDuplicates(sets []DuplicateSet, link bool) (int, int64, error) {
+	n := 0
+	var freed int64
+	for _, set := range sets {
+		keepInfo, err := os.Stat(set.keepPath)
+		if err != nil {
+			return n, freed, fmt.Errorf("%s: %v", set.Keep, err)
+		}
+		counted := []os.FileInfo{keepInfo}
+
+		for _, c := range set.Copies {
+			if link && (c.Linked || !c.CanLink) {
+				continue
+			}
+			info, err := os.Stat(c.path)
+			if err != nil {
+				return n, freed, fmt.Errorf("%s: %v", c.File, err)
+			}
+			if info.Size() != c.Size {
+				return n, freed, fmt.Errorf("%s changed since it was hashed", c.File)
+			}
+
+			if link {
+				err = linkCopy(set.keepPath, c)
+			} else {
+				err = os.Remove(c.path)
+			}
+			if err != nil {
+				return n, freed, fmt.Errorf("%s: %v", c.File, err)
+			}
+			n++
+			if !sameAsAny(info, counted) {
+				counted = append(counted, info)
+				freed += c.Size
+			}
+		}
+	}
+	return n, freed, nil
+}
+
+// linkCopy replaces a copy with a hard link to the kept image. The link is
+// made under a temporary name and renamed over the copy, so the copy is
+// never missing. ZSO files of the same game can differ byte for byte, so
+// they are compared first
+func linkCopy(keep string, c DuplicateCopy) error {
+	if c.compressed {
+		same, err := sameBytes(keep, c.path)
+		if err != nil {
+			return err
+		}
+		if !same {
+			return fmt.Errorf("ZSO files differ although their content is the same; remove the copy instead")
+		}
+	}
+
+	tmp := filepath.Join(filepath.Dir(c.path), "."+filepath.Base(c.path)+".link")
+	if err := os.Link(keep, tmp); err != nil {
+		return fmt.Errorf("failed to create hard link: %v", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// sameBytes compares two files
+func sameBytes(a, b string) (bool, error) {
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	ba, bb := make([]byte, copyBufferSize), make([]byte, copyBufferSize)
+	for {
+		na, errA := io.ReadFull(fa, ba)
+		nb, errB := io.ReadFull(fb, bb)
+		if na != nb || !bytes.Equal(ba[:na], bb[:nb]) {
+			return false, nil
+		}
+		if errA == io.EOF || errA == io.ErrUnexpectedEOF {
+			return errB == io.EOF || errB == io.ErrUnexpectedEOF, nil
+		}
+		if errA != nil {
+			return false, errA
+		}
+		if errB != nil {
+			return false, errB
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package library
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SectorSize is the ISO9660 logical sector size used by PS2 discs
+const SectorSize = 2048
+
+// Volume descriptor constants (ECMA-119)
+const (
+	descriptorStart      = 16
+	descriptorPrimary    = 1
+	descriptorTerminator = 255
+	maxDescriptors       = 32
+	dirFlagDirectory     = 0x02
+)
+
+// Volume is the primary volume descriptor of an ISO9660 image
+type Volume struct {
+	r io.ReaderAt
+
+	SystemID  string
+	VolumeID  string
+	Sectors   uint32
+	BlockSize uint16
+	// UDF is set when the image carries a UDF bridge, as PS2 DVDs do
+	UDF bool
+
+	root dirRecord
+}
+
+// dirRecord is a parsed ISO9660 directory record
+type dirRecord struct {
+	name   string
+	extent uint32
+	size   uint32
+	isDir  bool
+}
+
+// ReadVolume parses the primary volume descriptor and checks for a UDF
+// volume recognition sequence after the ISO9660 descriptors
+func ReadVolume(r io.ReaderAt) (*Volume, error) {
+	var v *Volume
+	buf := make([]byte, SectorSize)
+
+	sector := int64(descriptorStart)
+	for ; sector < descriptorStart+maxDescriptors; sector++ {
+		if _, err := r.ReadAt(buf, sector*SectorSize); err != nil {
+			return nil, fmt.Errorf("failed to read volume descriptor: %v", err)
+		}
+		if string(buf[1:6]) != "CD001" {
+			return nil, fmt.Errorf("not an ISO9660 image")
+		}
+
+		if buf[0] == descriptorPrimary && v == nil {
+			var err error
+			if v, err = parsePrimary(r, buf); err != nil {
+				return nil, err
+			}
+		}
+		if buf[0] == descriptorTerminator {
+			break
+		}
+	}
+	if v == nil {
+		return nil, fmt.Errorf("no primary volume descriptor")
+	}
+
+	// PS2 DVDs are UDF bridge discs: BEA01, NSR02/NSR03 and TEA01 follow
+	// the ISO9660 terminator. PS2 CDs are plain ISO9660
+	for sector++; sector < descriptorStart+maxDescriptors; sector++ {
+		if _, err := r.ReadAt(buf, sector*SectorSize); err != nil {
+			break
+		}
+		id := string(buf[1:6])
+		if id == "NSR02" || id == "NSR03" {
+			v.UDF = true
+		}
+		if id != "BEA01" && id != "NSR02" && id != "NSR03" && id != "TEA01" {
+			break
+		}
+	}
+	return v, nil
+}
+
+func parsePrimary(r io.ReaderAt, buf []byte) (*Volume, error) {
+	v := &Volume{
+		r:         r,
+		SystemID:  strings.TrimSpace(string(buf[8:40])),
+		VolumeID:  strings.TrimSpace(string(buf[40:72])),
+		Sectors:   binary.LittleEndian.Uint32(buf[80:84]),
+		BlockSize: binary.LittleEndian.Uint16(buf[128:130]),
+	}
+	if v.BlockSize != SectorSize {
+		return nil, fmt.Errorf("unsupported logical block size %d", v.BlockSize)
+	}
+
+	root, ok := parseDirRecord(buf[156:190])
+	if !ok {
+		return nil, fmt.Errorf("invalid root directory record")
+	}
+	v.root = root
+	return v, nil
+}
+
+// Size returns the volume size in bytes as recorded in the descriptor
+func (v *Volume) Size() int64 {
+	return int64(v.Sectors) * SectorSize
+}
+
+// maxFileSize bounds ReadFile, which is only meant for small files such as
+// SYSTEM.CNF, so a corrupt record cannot cause a huge allocation
+const maxFileSize = 64 << 10
+
+// ReadFile returns the contents of a file, looked up case-insensitively
+// and ignoring ISO9660 version suffixes (";1")
+func (v *Volume) ReadFile(path string) ([]byte, error) {
+	rec, err := v.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	if rec.isDir {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if rec.size > maxFileSize {
+		return nil, fmt.Errorf("%s is implausibly large", path)
+	}
+
+	data := make([]byte, rec.size)
+	if _, err := v.r.ReadAt(data, int64(rec.extent)*SectorSize); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return data, nil
+}
+
+func (v *Volume) lookup(path string) (dirRecord, error) {
+	cur := v.root
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if !cur.isDir {
+			return dirRecord{}, fmt.Errorf("%s: not a directory", cur.name)
+		}
+		entries, err := v.readDir(cur)
+		if err != nil {
+			return dirRecord{}, err
+		}
+
+		found := false
+		for _, e := range entries {
+			if strings.EqualFold(e.name, part) {
+				cur, found = e, true
+				break
+			}
+		}
+		if !found {
+			return dirRecord{}, fmt.Errorf("%s not found", path)
+		}
+	}
+	return cur, nil
+}
+
+// maxDirSize bounds directory reads on corrupt images
+const maxDirSize = 1 << 20
+
+func (v *Volume) readDir(dir dirRecord) ([]dirRecord, error) {
+	if dir.size > maxDirSize {
+		return nil, fmt.Errorf("directory %s is implausibly large", dir.name)
+	}
+	data := make([]byte, dir.size)
+	if _, err := v.r.ReadAt(data, int64(dir.extent)*SectorSize); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read directory: %v", err)
+	}
+
+	var entries []dirRecord
+	for off := 0; off < len(data); {
+		length := int(data[off])
+		if length == 0 {
+			// Records never span sectors; skip the padding to the next one
+			off = (off/SectorSize + 1) * SectorSize
+			continue
+		}
+		if off+length > len(data) {
+			break
+		}
+		if rec, ok := parseDirRecord(data[off : off+length]); ok {
+			entries = append(entries, rec)
+		}
+		off += length
+	}
+	return entries, nil
+}
+
+func parseDirRecord(b []byte) (dirRecord, bool) {
+	if len(b) < 34 || int(b[0]) > len(b) {
+		return dirRecord{}, false
+	}
+	nameLen := int(b[32])
+	if 33+nameLen > len(b) {
+		return dirRecord{}, false
+	}
+
+	name := string(b[33 : 33+nameLen])
+	switch name {
+	case "\x00":
+		name = "."
+	case "\x01":
+		name = ".."
+	}
+	if i := strings.IndexByte(name, ';'); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.TrimSuffix(name, ".")
+
+	return dirRecord{
+		name:   name,
+		extent: binary.LittleEndian.Uint32(b[2:6]),
+		size:   binary.LittleEndian.Uint32(b[10:14]),
+		isDir:  b[25]&dirFlagDirectory != 0,
+	}, true
+}
@@ -0,0 +1,220 @@
+package library
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Media is the OPL folder a game belongs in
+type Media string
+
+const (
+	MediaDVD Media = "DVD"
+	MediaCD  Media = "CD"
+)
+
+// MediaDirs are the folders OPL scans, in the order it lists them
+var MediaDirs = []Media{MediaDVD, MediaCD}
+
+// Game is one disc image in the library
+type Game struct {
+	Path      string `json:"path"`
+	File      string `json:"file"`
+	Media     Media  `json:"media"`
+	Size      int64  `json:"size"`
+	ID        string `json:"id,omitempty"`
+	Title     string `json:"title"`
+	DBTitle   string `json:"db_title,omitempty"` // from the title database
+	Languages string `json:"languages,omitempty"`
+	Region    Region `json:"region,omitempty"`
+	Version   string `json:"version,omitempty"`
+	VideoMode string `json:"video_mode,omitempty"`
+	VolumeID  string `json:"volume_id,omitempty"`
+	// Compressed is set for ZSO images; Size is then the size on disk
+	Compressed bool `json:"compressed,omitempty"`
+	// Detected is the media type read from the disc structure, and
+	// DetectedBy explains how it was decided
+	Detected   Media  `json:"detected_media,omitempty"`
+	DetectedBy string `json:"detected_by,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Misplaced reports whether the image sits in the wrong media folder
+func (g *Game) Misplaced() bool {
+	return g.Detected != "" && g.Detected != g.Media
+}
+
+// RelPath returns the path relative to the games directory, e.g.
+// DVD/SLUS_203.12.Title.iso
+func (g *Game) RelPath() string {
+	return string(g.Media) + "/" + g.File
+}
+
+// Catalog is the scanned contents of a games directory
+type Catalog struct {
+	Root  string `json:"root"`
+	Games []Game `json:"games"`
+
+	titles TitleLookup
+}
+
+// Misplaced returns the games whose folder does not match their media
+func (c *Catalog) Misplaced() []Game {
+	var bad []Game
+	for _, g := range c.Games {
+		if g.Misplaced() {
+			bad = append(bad, g)
+		}
+	}
+	return bad
+}
+
+// Errors returns the games that could not be parsed
+func (c *Catalog) Errors() []Game {
+	var bad []Game
+	for _, g := range c.Games {
+		if g.Error != "" {
+			bad = append(bad, g)
+		}
+	}
+	return bad
+}
+
+// IsImage reports whether a file name has an extension OPL loads
+func IsImage(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".iso") || IsZSO(name)
+}
+
+// Scan reads every image in the DVD/ and CD/ folders under root. OPL does
+// not recurse, so neither does Scan. Unreadable images are kept in the
+// catalog with Error set rather than failing the scan
+func Scan(root string) (*Catalog, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("games directory: %v", err)
+	}
+
+	cat := &Catalog{Root: root}
+	for _, media := range MediaDirs {
+		dir := filepath.Join(root, string(media))
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s: %v", dir, err)
+		}
+
+		for _, e := range entries {
+			if e.IsDir() || !IsImage(e.Name()) {
+				continue
+			}
+			cat.Games = append(cat.Games, ScanFile(filepath.Join(dir, e.Name()), media))
+		}
+	}
+
+	sort.SliceStable(cat.Games, func(i, j int) bool {
+		a, b := cat.Games[i], cat.Games[j]
+		if a.Media != b.Media {
+			return a.Media == MediaDVD
+		}
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	})
+	return cat, nil
+}
+
+// ScanFile parses a single image. The title comes from the file name, with
+// an OPL-style "SLUS_203.12." prefix removed
+func ScanFile(path string, media Media) Game {
+	g := Game{
+		Path:  path,
+		File:  filepath.Base(path),
+		Media: media,
+		Title: TitleFromFile(filepath.Base(path)),
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		g.Error = err.Error()
+		return g
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil {
+		g.Size = info.Size()
+	}
+
+	if IsZSO(path) {
+		g.Compressed = true
+		z, err := readZSOHeader(f, g.Size)
+		if err != nil {
+			g.Error = err.Error()
+			return g
+		}
+		scanVolume(&g, z)
+		return g
+	}
+	scanVolume(&g, f)
+	return g
+}
+
+// ScanRaw parses a BIN/CUE image through the converter, so it can be
+// checked before being written out as an ISO. Size is the converted size
+func ScanRaw(path string) Game {
+	g := Game{
+		Path:  path,
+		File:  filepath.Base(path),
+		Title: TitleFromFile(filepath.Base(path)),
+	}
+
+	r, err := OpenRaw(path)
+	if err != nil {
+		g.Error = err.Error()
+		return g
+	}
+	defer r.Close()
+
+	g.Size = r.Size()
+	scanVolume(&g, r)
+	return g
+}
+
+// scanVolume fills in what the volume descriptor and SYSTEM.CNF tell
+func scanVolume(g *Game, r io.ReaderAt) {
+	vol, err := ReadVolume(r)
+	if err != nil {
+		g.Error = err.Error()
+		return
+	}
+	g.VolumeID = vol.VolumeID
+	g.Detected, g.DetectedBy = Classify(vol, g.Size)
+
+	data, err := vol.ReadFile("SYSTEM.CNF")
+	if err != nil {
+		g.Error = fmt.Sprintf("not a PS2 disc: %v", err)
+		return
+	}
+	cnf, err := ParseSystemCNF(data)
+	if err != nil {
+		g.Error = err.Error()
+		return
+	}
+
+	g.ID = cnf.GameID
+	g.Region = RegionOf(cnf.GameID)
+	g.Version = cnf.Version
+	g.VideoMode = cnf.VideoMode
+}
+
+// TitleFromFile strips the extension and any leading game ID from an image
+// file name
+func TitleFromFile(name string) string {
+	title := strings.TrimSuffix(name, filepath.Ext(name))
+	if len(title) > 12 && IsGameID(strings.ToUpper(title[:11])) && title[11] == '.' {
+		title = title[12:]
+	}
+	return title
+}
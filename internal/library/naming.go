@@ -0,0 +1,112 @@
+package library
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// OPL name limits: the title part of "SLUS_203.12.Title.iso" may be at most
+// 64 characters or OPL skips the image, and paths are built in 256-byte
+// buffers on the PS2
+const (
+	MaxTitleLength = 64
+	MaxPathLength  = 255
+	gameIDLength   = 11
+)
+
+// invalidNameChars cannot be used in file names on SMB shares
+const invalidNameChars = `\/:*?"<>|`
+
+// transliterations covers the accented and typographic characters common in
+// game titles; OPL's font only renders ASCII
+var transliterations = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Æ': "AE", 'Ç': "C",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I",
+	'Ñ': "N", 'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Œ': "OE",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ý': "Y",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae", 'ç': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i",
+	'ñ': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y", 'ß': "ss",
+	'Ā': "A", 'Ē': "E", 'Ī': "I", 'Ō': "O", 'Ū': "U", 'ā': "a", 'ē': "e", 'ī': "i", 'ō': "o", 'ū': "u",
+	'‘': "'", '’': "'", '“': "'", '”': "'", '–': "-", '—': "-", '…': "...",
+	'™': "", '®': "", '©': "",
+}
+
+// NameProblems lists what would stop OPL from showing a file correctly
+func NameProblems(media Media, file string) []string {
+	var problems []string
+
+	title := TitleFromFile(file)
+	var nonASCII, invalid []string
+	for _, r := range title {
+		switch {
+		case r >= utf8.RuneSelf:
+			nonASCII = appendUnique(nonASCII, string(r))
+		case r < 0x20 || strings.ContainsRune(invalidNameChars, r):
+			invalid = appendUnique(invalid, fmt.Sprintf("%q", r))
+		}
+	}
+	if len(nonASCII) > 0 {
+		problems = append(problems, "characters OPL cannot display: "+strings.Join(nonASCII, " "))
+	}
+	if len(invalid) > 0 {
+		problems = append(problems, "characters not allowed on SMB: "+strings.Join(invalid, " "))
+	}
+	if n := len(title); n > MaxTitleLength {
+		problems = append(problems, fmt.Sprintf("title is %d characters; OPL skips titles over %d", n, MaxTitleLength))
+	}
+	if n := len(string(media)) + 1 + len(file); n > MaxPathLength {
+		problems = append(problems, fmt.Sprintf("path is %d bytes; OPL's limit is %d", n, MaxPathLength))
+	}
+	return problems
+}
+
+// NormalizeTitle makes a title safe for OPL: ASCII only, no characters SMB
+// rejects, single spaces and at most MaxTitleLength characters
+func NormalizeTitle(title string) string {
+	var b strings.Builder
+	for _, r := range title {
+		switch {
+		case r == ':':
+			b.WriteString(" - ")
+		case r == '/' || r == '\\' || r == '|':
+			b.WriteString("-")
+		case r < 0x20 || strings.ContainsRune(invalidNameChars, r):
+			// dropped
+		case r >= utf8.RuneSelf:
+			b.WriteString(transliterations[r])
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	out := strings.Join(strings.Fields(b.String()), " ")
+	out = strings.ReplaceAll(out, "- -", "-")
+	out = strings.Trim(out, " .-")
+
+	if len(out) > MaxTitleLength {
+		cut := out[:MaxTitleLength]
+		if i := strings.LastIndexByte(cut, ' '); i > MaxTitleLength/2 {
+			cut = cut[:i]
+		}
+		out = strings.Trim(cut, " .-")
+	}
+	return out
+}
+
+// OPLFileName builds the "SLUS_203.12.Title.iso" name OPL expects; ext is
+// ".iso" or ".zso"
+func OPLFileName(id, title, ext string) string {
+	return id + "." + title + ext
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
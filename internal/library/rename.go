@@ -0,0 +1,264 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Rename is one planned file name change inside a media folder
+type Rename struct {
+	Media    Media    `json:"media"`
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Problems []string `json:"problems,omitempty"`
+	Skip     string   `json:"skip,omitempty"`
+}
+
+// Changed reports whether the rename would actually move the file
+func (r Rename) Changed() bool {
+	return r.Skip == "" && r.From != r.To
+}
+
+// PlanRenames proposes an OPL-style name for every game in the catalog,
+// using the game ID read from the disc. Names that would collide with an
+// existing file or another rename are skipped
+func PlanRenames(cat *Catalog) []Rename {
+	// Names that exist now are never reused, even when their file is being
+	// renamed away in the same batch
+	taken := make(map[string]bool)
+	for _, g := range cat.Games {
+		taken[strings.ToLower(g.RelPath())] = true
+	}
+
+	var plan []Rename
+	for _, g := range cat.Games {
+		r := Rename{
+			Media:    g.Media,
+			From:     g.File,
+			To:       g.File,
+			Problems: NameProblems(g.Media, g.File),
+		}
+
+		if g.ID == "" {
+			r.Skip = "no game ID"
+			if g.Error != "" {
+				r.Skip += ": " + g.Error
+			}
+			plan = append(plan, r)
+			continue
+		}
+
+		prefix := strings.ToUpper(g.File)
+		if len(prefix) > gameIDLength && IsGameID(prefix[:gameIDLength]) && prefix[:gameIDLength] != g.ID {
+			r.Problems = append(r.Problems, fmt.Sprintf("file name says %s but the disc is %s", prefix[:gameIDLength], g.ID))
+		}
+
+		title := NormalizeTitle(g.DisplayTitle())
+		if title == "" {
+			title = NormalizeTitle(g.VolumeID)
+		}
+		if title == "" {
+			title = g.ID
+		}
+		r.To = OPLFileName(g.ID, title, imageExt(g.File))
+
+		target := strings.ToLower(string(g.Media) + "/" + r.To)
+		if r.Changed() && taken[target] && !strings.EqualFold(r.From, r.To) {
+			r.Skip = fmt.Sprintf("%s already exists", r.To)
+		} else {
+			taken[target] = true
+		}
+		plan = append(plan, r)
+	}
+	return plan
+}
+
+// UndoLog records a batch of renames so it can be reverted
+type UndoLog struct {
+	Root    string    `json:"root"`
+	Time    time.Time `json:"time"`
+	Renames []Rename  `json:"renames"`
+}
+
+// ApplyRenames performs every changed rename under root. The undo log is
+// written to logPath first; if any rename fails, the ones already done are
+// reverted so the batch is all-or-nothing
+func ApplyRenames(root string, plan []Rename, logPath string) (int, error) {
+	var todo []Rename
+	for _, r := range plan {
+		if r.Changed() {
+			todo = append(todo, Rename{Media: r.Media, From: r.From, To: r.To})
+		}
+	}
+	if len(todo) == 0 {
+		return 0, nil
+	}
+
+	log := UndoLog{Root: root, Time: time.Now(), Renames: todo}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create undo log directory: %v", err)
+	}
+	if err := writeNew(logPath, data); err != nil {
+		return 0, fmt.Errorf("failed to write undo log: %v", err)
+	}
+
+	if err := renameAll(renamePairs(root, todo, false)); err != nil {
+		os.Remove(logPath)
+		return 0, err
+	}
+	return len(todo), nil
+}
+
+// Undo reverts the renames recorded in an undo log and deletes the log
+func Undo(logPath string) (*UndoLog, error) {
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read undo log: %v", err)
+	}
+	var log UndoLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("invalid undo log %s: %v", logPath, err)
+	}
+
+	if err := renameAll(renamePairs(log.Root, log.Renames, true)); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(logPath); err != nil {
+		return nil, fmt.Errorf("renames reverted but the undo log could not be removed: %v", err)
+	}
+	return &log, nil
+}
+
+// LatestUndoLog returns the newest undo log in dir
+func LatestUndoLog(dir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "rename-*.json"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no rename to undo")
+	}
+	// Names embed a sortable timestamp
+	sort.Strings(matches)
+	return matches[len(matches)-1], nil
+}
+
+// UndoLogName returns a timestamped file name for a new undo log. The
+// nanoseconds keep runs within the same second apart
+func UndoLogName(t time.Time) string {
+	return "rename-" + t.Format("20060102-150405.000000000") + ".json"
+}
+
+// writeNew writes a file that must not exist yet, so an undo log is never
+// replaced by another
+func writeNew(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// renamePairs turns renames into source/target paths, swapped when
+// reverting
+func renamePairs(root string, renames []Rename, reverse bool) [][2]string {
+	pairs := make([][2]string, len(renames))
+	for i, r := range renames {
+		from, to := mediaPath(root, r.Media, r.From), mediaPath(root, r.Media, r.To)
+		if reverse {
+			from, to = to, from
+		}
+		pairs[i] = [2]string{from, to}
+	}
+	return pairs
+}
+
+// renameAll renames each source to its target and rolls back on the first
+// failure
+func renameAll(pairs [][2]string) error {
+	for i, p := range pairs {
+		if err := renameNoReplace(p[0], p[1]); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				os.Rename(pairs[j][1], pairs[j][0])
+			}
+			return fmt.Errorf("failed to rename %s: %v (no files were changed)", filepath.Base(p[0]), err)
+		}
+	}
+	return nil
+}
+
+// imageExt returns the lower-case extension of an image, which renames keep
+func imageExt(file string) string {
+	if IsZSO(file) {
+		return ".zso"
+	}
+	return ".iso"
+}
+
+func mediaDir(root string, media Media) string {
+	return filepath.Join(root, string(media))
+}
+
+func mediaPath(root string, media Media, file string) string {
+	return filepath.Join(root, string(media), file)
+}
+
+// mkdirLike creates dir with the mode and ownership of ref, so a new media
+// folder stays readable by the share user
+func mkdirLike(dir, ref string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	info, err := os.Stat(ref)
+	if err != nil {
+		return os.Mkdir(dir, 0755)
+	}
+	if err := os.Mkdir(dir, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		os.Chown(dir, int(st.Uid), int(st.Gid))
+	}
+	return nil
+}
+
+// renameNoReplace renames from to to, failing if to already exists. A hard
+// link makes the check atomic; filesystems without links fall back to a
+// check followed by rename
+func renameNoReplace(from, to string) error {
+	err := os.Link(from, to)
+	if err == nil {
+		return os.Remove(from)
+	}
+
+	if errors.Is(err, os.ErrExist) {
+		// A case-only rename on a case-insensitive filesystem
+		a, aerr := os.Stat(from)
+		b, berr := os.Stat(to)
+		if aerr == nil && berr == nil && os.SameFile(a, b) {
+			return os.Rename(from, to)
+		}
+		return fmt.Errorf("%s already exists", filepath.Base(to))
+	}
+
+	if _, serr := os.Lstat(to); serr == nil {
+		return fmt.Errorf("%s already exists", filepath.Base(to))
+	}
+	return os.Rename(from, to)
+}
@@ -0,0 +1,118 @@
+package library
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Region is the market a game was released for, derived from its ID prefix
+type Region string
+
+const (
+	RegionUSA     Region = "NTSC-U"
+	RegionEurope  Region = "PAL"
+	RegionJapan   Region = "NTSC-J"
+	RegionKorea   Region = "NTSC-K"
+	RegionChina   Region = "NTSC-C"
+	RegionUnknown Region = "unknown"
+)
+
+// regionPrefixes maps the publisher/region part of a game ID to a region
+var regionPrefixes = map[string]Region{
+	"SLUS": RegionUSA, "SCUS": RegionUSA,
+	"SLES": RegionEurope, "SCES": RegionEurope, "SLED": RegionEurope, "SCED": RegionEurope,
+	"SLPS": RegionJapan, "SLPM": RegionJapan, "SCPS": RegionJapan, "SCPM": RegionJapan,
+	"SLAJ": RegionJapan, "SCAJ": RegionJapan, "PBPX": RegionJapan,
+	"SLKA": RegionKorea, "SCKA": RegionKorea,
+	"SCCS": RegionChina, "SLCS": RegionChina,
+}
+
+// gameIDPattern matches IDs like SLUS_203.12
+var gameIDPattern = regexp.MustCompile(`^[A-Z]{4}_\d{3}\.\d{2}$`)
+
+// IsGameID reports whether s is a well-formed PS2 game ID
+func IsGameID(s string) bool {
+	return gameIDPattern.MatchString(s)
+}
+
+// serialPattern matches the ways databases write game IDs: "SLUS-20312",
+// "SLUS_203.12", "slus20312"
+var serialPattern = regexp.MustCompile(`^([A-Z]{4})[-_ ]?(\d{3})\.?(\d{2})$`)
+
+// NormalizeGameID converts a serial in any common notation to the
+// "SLUS_203.12" form used in SYSTEM.CNF
+func NormalizeGameID(s string) (string, bool) {
+	m := serialPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if m == nil {
+		return "", false
+	}
+	return m[1] + "_" + m[2] + "." + m[3], true
+}
+
+// RegionOf returns the region encoded in a game ID prefix
+func RegionOf(id string) Region {
+	if len(id) < 4 {
+		return RegionUnknown
+	}
+	if r, ok := regionPrefixes[id[:4]]; ok {
+		return r
+	}
+	return RegionUnknown
+}
+
+// SystemCNF holds the boot parameters from a PS2 disc's SYSTEM.CNF
+type SystemCNF struct {
+	Boot      string
+	GameID    string
+	Version   string
+	VideoMode string
+}
+
+// ParseSystemCNF extracts the boot executable and game ID. PS1 discs use
+// BOOT instead of BOOT2 and are rejected, since OPL cannot run them
+func ParseSystemCNF(data []byte) (*SystemCNF, error) {
+	cnf := &SystemCNF{}
+	ps1 := false
+
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.ToUpper(strings.TrimSpace(key)) {
+		case "BOOT2":
+			cnf.Boot = value
+		case "BOOT":
+			ps1 = true
+		case "VER":
+			cnf.Version = value
+		case "VMODE":
+			cnf.VideoMode = strings.ToUpper(value)
+		}
+	}
+
+	if cnf.Boot == "" {
+		if ps1 {
+			return nil, fmt.Errorf("PlayStation 1 disc (SYSTEM.CNF has BOOT, not BOOT2)")
+		}
+		return nil, fmt.Errorf("SYSTEM.CNF has no BOOT2 entry")
+	}
+
+	// cdrom0:\SLUS_203.12;1 -> SLUS_203.12
+	exe := cnf.Boot
+	if i := strings.LastIndexAny(exe, `\/:`); i >= 0 {
+		exe = exe[i+1:]
+	}
+	if i := strings.IndexByte(exe, ';'); i >= 0 {
+		exe = exe[:i]
+	}
+	exe = strings.ToUpper(strings.TrimSpace(exe))
+	if !IsGameID(exe) {
+		return nil, fmt.Errorf("boot executable %q is not a game ID", exe)
+	}
+	cnf.GameID = exe
+	return cnf, nil
+}
@@ -0,0 +1,44 @@
+package library
+
+// TitleInfo is what a title database knows about a game ID
+type TitleInfo struct {
+	Title     string `json:"title"`
+	Region    string `json:"region,omitempty"`
+	Languages string `json:"languages,omitempty"`
+}
+
+// TitleLookup returns the database entry for a game ID
+type TitleLookup func(id string) (TitleInfo, bool)
+
+// SetTitles attaches a title database to the catalog: every game gets its
+// database title, and games added later are looked up too
+func (c *Catalog) SetTitles(lookup TitleLookup) {
+	c.titles = lookup
+	for i := range c.Games {
+		applyTitle(&c.Games[i], lookup)
+	}
+}
+
+func applyTitle(g *Game, lookup TitleLookup) {
+	if lookup == nil || g.ID == "" {
+		return
+	}
+	info, ok := lookup(g.ID)
+	if !ok || info.Title == "" {
+		return
+	}
+	g.DBTitle = info.Title
+	g.Languages = info.Languages
+	if g.Region == RegionUnknown && info.Region != "" {
+		g.Region = Region(info.Region)
+	}
+}
+
+// DisplayTitle returns the database title when known, otherwise the title
+// taken from the file name
+func (g *Game) DisplayTitle() string {
+	if g.DBTitle != "" {
+		return g.DBTitle
+	}
+	return g.Title
+}
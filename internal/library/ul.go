@@ -0,0 +1,358 @@
+package library
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// USBExtreme (USBAdvance) layout as read by OPL: ul.cfg holds one 64-byte
+// entry per game and the image is split into 1 GiB parts named
+// ul.<CRC32 of name>.<game ID>.<part> so each fits on FAT32
+const (
+	ULConfigName  = "ul.cfg"
+	ULPartSize    = 1 << 30
+	ULNameLength  = 32
+	ulEntrySize   = 64
+	ulImageLength = 15
+	ulMediaCD     = 0x12
+	ulMediaDVD    = 0x14
+	// ulCompatOffset holds 0x08, which USBExtreme expects in every entry
+	ulCompatOffset = 53
+	ulImagePrefix  = "ul."
+)
+
+// ULGame is one entry of ul.cfg
+type ULGame struct {
+	Name  string `json:"name"`
+	ID    string `json:"id"`
+	Parts int    `json:"parts"`
+	Media Media  `json:"media"`
+	// Size is the total size of the parts found on disk
+	Size    int64    `json:"size"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+// PartName returns the file name of part i
+func (g ULGame) PartName(i int) string {
+	return fmt.Sprintf("ul.%08X.%s.%02x", ULCRC32(g.Name), g.ID, i)
+}
+
+// ULCRC32 is OPL's USBA_crc32 name hash. It is not the standard CRC-32:
+// the table is built with the polynomial applied on clear top bits and
+// stored reversed, and the register starts from the last table value. The
+// terminating NUL is hashed too
+func ULCRC32(name string) uint32 {
+	var table [256]uint32
+	var crc uint32
+	for i := 0; i < 256; i++ {
+		crc = uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc <<= 1
+			} else {
+				crc = crc<<1 ^ 0x04c11db7
+			}
+		}
+		table[255-i] = crc
+	}
+
+	for _, b := range append([]byte(name), 0) {
+		crc = table[b^byte(crc>>24)] ^ crc<<8
+	}
+	return crc
+}
+
+// ReadULConfig parses dir/ul.cfg and checks which parts are present
+func ReadULConfig(dir string) ([]ULGame, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ULConfigName))
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%ulEntrySize != 0 {
+		return nil, fmt.Errorf("%s is %d bytes, not a multiple of %d", ULConfigName, len(data), ulEntrySize)
+	}
+
+	var games []ULGame
+	for off := 0; off < len(data); off += ulEntrySize {
+		e := data[off : off+ulEntrySize]
+		image := cString(e[ULNameLength : ULNameLength+ulImageLength])
+		if !strings.HasPrefix(image, ulImagePrefix) {
+			return nil, fmt.Errorf("entry %d: invalid image name %q", off/ulEntrySize+1, image)
+		}
+
+		g := ULGame{
+			Name:  cString(e[:ULNameLength]),
+			ID:    strings.TrimPrefix(image, ulImagePrefix),
+			Parts: int(e[ULNameLength+ulImageLength]),
+			Media: MediaDVD,
+		}
+		if e[ULNameLength+ulImageLength+1] == ulMediaCD {
+			g.Media = MediaCD
+		}
+		for i := 0; i < g.Parts; i++ {
+			info, err := os.Stat(filepath.Join(dir, g.PartName(i)))
+			if err != nil {
+				g.Missing = append(g.Missing, g.PartName(i))
+				continue
+			}
+			g.Size += info.Size()
+		}
+		games = append(games, g)
+	}
+	return games, nil
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// ULImage reads the parts of a USBExtreme game as one image. It implements
+// io.ReaderAt
+type ULImage struct {
+	parts []readerAtCloser
+	size  int64
+}
+
+// OpenUL opens every part of a game. All parts but the last must be
+// exactly ULPartSize, or the offsets OPL computes would be wrong
+func OpenUL(dir string, g ULGame) (*ULImage, error) {
+	return openUL(dir, g, false)
+}
+
+// openUL opens the parts, with direct I/O to verify freshly written ones
+func openUL(dir string, g ULGame, direct bool) (*ULImage, error) {
+	if len(g.Missing) > 0 {
+		return nil, fmt.Errorf("missing parts: %s", strings.Join(g.Missing, ", "))
+	}
+	if g.Parts == 0 {
+		return nil, fmt.Errorf("%s has no parts", g.ID)
+	}
+
+	open := openFile
+	if direct {
+		open = openDirect
+	}
+
+	u := &ULImage{}
+	for i := 0; i < g.Parts; i++ {
+		f, size, err := open(filepath.Join(dir, g.PartName(i)))
+		if err != nil {
+			u.Close()
+			return nil, err
+		}
+		u.parts = append(u.parts, f)
+
+		if i < g.Parts-1 && size != ULPartSize {
+			u.Close()
+			return nil, fmt.Errorf("%s is %d bytes; every part but the last must be 1 GiB", g.PartName(i), size)
+		}
+		u.size += size
+	}
+	return u, nil
+}
+
+// Size returns the size of the joined image
+func (u *ULImage) Size() int64 {
+	return u.size
+}
+
+// Close closes every part
+func (u *ULImage) Close() error {
+	for _, f := range u.parts {
+		f.Close()
+	}
+	return nil
+}
+
+// ReadAt reads the joined image, crossing part boundaries as needed
+func (u *ULImage) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= u.size {
+			return n, io.EOF
+		}
+		part := pos / ULPartSize
+		want := min(int64(len(p)-n), ULPartSize-pos%ULPartSize)
+		m, err := u.parts[part].ReadAt(p[n:n+int(want)], pos%ULPartSize)
+		n += m
+		if err != nil && !(err == io.EOF && int64(m) == want) {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// AddUL imports a USBExtreme game into the catalog as an ISO, through the
+// same checks and verified copy as Add. The ul.cfg name becomes the title
+func AddUL(cat *Catalog, dir string, ul ULGame, opts AddOptions) (*AddResult, error) {
+	g := Game{
+		Path:  filepath.Join(dir, ul.PartName(0)),
+		File:  ul.PartName(0),
+		Title: ul.Name,
+	}
+
+	u, err := OpenUL(dir, ul)
+	if err != nil {
+		return nil, err
+	}
+	g.Size = u.Size()
+	scanVolume(&g, u)
+	u.Close()
+
+	return addImage(cat, g, ".iso", func() (source, error) { return OpenUL(dir, ul) }, opts)
+}
+
+// ExportUL splits a library image (ISO or ZSO) into USBExtreme parts in dir
+// and registers it in ul.cfg. The parts are read back and compared with the
+// source's SHA-1 before ul.cfg is updated
+func ExportUL(dir string, g Game, progress func(phase string, done, total int64)) (*ULGame, string, error) {
+	if g.ID == "" {
+		return nil, "", fmt.Errorf("%s has no game ID", g.File)
+	}
+
+	existing, err := ReadULConfig(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, "", err
+	}
+	for _, e := range existing {
+		if e.ID == g.ID {
+			return nil, "", fmt.Errorf("%s is already in %s as %q", g.ID, ULConfigName, e.Name)
+		}
+	}
+
+	name := NormalizeTitle(g.DisplayTitle())
+	if name == "" {
+		name = g.ID
+	}
+	if len(name) > ULNameLength {
+		name = strings.TrimRight(name[:ULNameLength], " .-")
+	}
+	media := g.Detected
+	if media == "" {
+		media = g.Media
+	}
+
+	var in source
+	if g.Compressed {
+		in, err = OpenZSO(g.Path)
+	} else {
+		in, err = openSource(g.Path, false)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	defer in.Close()
+
+	ul := &ULGame{
+		Name:  name,
+		ID:    g.ID,
+		Parts: int((in.Size() + ULPartSize - 1) / ULPartSize),
+		Media: media,
+		Size:  in.Size(),
+	}
+	if ul.Parts > 255 {
+		return nil, "", fmt.Errorf("%s is too large for %s", g.File, ULConfigName)
+	}
+
+	sum, created, err := writeULParts(dir, ul, in, phaseProgress(progress, "split"))
+	if err != nil {
+		removeULParts(dir, ul, created)
+		return nil, "", err
+	}
+
+	u, err := openUL(dir, *ul, true)
+	if err == nil {
+		var verify string
+		verify, err = hashReader(u, u.Size(), phaseProgress(progress, "verify"))
+		u.Close()
+		if err == nil && verify != sum {
+			err = fmt.Errorf("parts do not match the source")
+		}
+	}
+	if err != nil {
+		removeULParts(dir, ul, ul.Parts)
+		return nil, "", fmt.Errorf("failed to verify: %v", err)
+	}
+
+	if err := appendULConfig(dir, ul); err != nil {
+		removeULParts(dir, ul, ul.Parts)
+		return nil, "", err
+	}
+	return ul, sum, nil
+}
+
+// writeULParts writes every part, refusing to overwrite existing files, and
+// returns how many parts it created
+func writeULParts(dir string, ul *ULGame, in source, progress func(done, total int64)) (string, int, error) {
+	h := sha1.New()
+	pw := &progressWriter{total: in.Size(), fn: progress}
+	buf := make([]byte, copyBufferSize)
+
+	for i := 0; i < ul.Parts; i++ {
+		path := filepath.Join(dir, ul.PartName(i))
+		out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return "", i, err
+		}
+
+		start := int64(i) * ULPartSize
+		part := io.NewSectionReader(in, start, min(ULPartSize, in.Size()-start))
+		_, err = io.CopyBuffer(io.MultiWriter(out, h, pw), part, buf)
+		if err == nil {
+			err = out.Sync()
+		}
+		out.Close()
+		if err != nil {
+			return "", i + 1, fmt.Errorf("failed to write %s: %v", ul.PartName(i), err)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), ul.Parts, nil
+}
+
+func removeULParts(dir string, ul *ULGame, n int) {
+	for i := 0; i < n; i++ {
+		os.Remove(filepath.Join(dir, ul.PartName(i)))
+	}
+}
+
+// appendULConfig adds an entry to ul.cfg, replacing the file atomically
+func appendULConfig(dir string, ul *ULGame) error {
+	path := filepath.Join(dir, ULConfigName)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	e := make([]byte, ulEntrySize)
+	copy(e[:ULNameLength], ul.Name)
+	copy(e[ULNameLength:ULNameLength+ulImageLength-1], ulImagePrefix+ul.ID)
+	e[ULNameLength+ulImageLength] = byte(ul.Parts)
+	e[ULNameLength+ulImageLength+1] = ulMediaDVD
+	if ul.Media == MediaCD {
+		e[ULNameLength+ulImageLength+1] = ulMediaCD
+	}
+	e[ulCompatOffset] = 0x08
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, e...), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", ULConfigName, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %v", ULConfigName, err)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package library
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Hashes are the checksums DAT files list for an image. For ZSO images
+// they cover the decompressed ISO
+type Hashes struct {
+	Size  int64  `json:"size"`
+	CRC32 string `json:"crc32"`
+	MD5   string `json:"md5"`
+	SHA1  string `json:"sha1"`
+}
+
+// HashImage computes the checksums of an image in a single read
+func HashImage(g Game, progress func(n int64)) (Hashes, error) {
+	var in source
+	var err error
+	if IsZSO(g.Path) {
+		in, err = OpenZSO(g.Path)
+	} else {
+		in, err = openSource(g.Path, false)
+	}
+	if err != nil {
+		return Hashes{}, err
+	}
+	defer in.Close()
+
+	c, m, s := crc32.NewIEEE(), md5.New(), sha1.New()
+	w := io.MultiWriter(c, m, s, &progressWriter{fn: func(done, _ int64) {
+		if progress != nil {
+			progress(done)
+		}
+	}})
+	if _, err := io.CopyBuffer(w, io.NewSectionReader(in, 0, in.Size()), make([]byte, copyBufferSize)); err != nil {
+		return Hashes{}, err
+	}
+	return Hashes{
+		Size:  in.Size(),
+		CRC32: hex.EncodeToString(c.Sum(nil)),
+		MD5:   hex.EncodeToString(m.Sum(nil)),
+		SHA1:  hex.EncodeToString(s.Sum(nil)),
+	}, nil
+}
+
+// HashCache remembers checksums by path, size and modification time, so
+// unchanged images are not read again
+type HashCache struct {
+	path    string
+	mu      sync.Mutex
+	Entries map[string]cachedHashes `json:"entries"`
+}
+
+type cachedHashes struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	Hashes  Hashes `json:"hashes"`
+}
+
+// LoadHashCache reads the cache at path; a missing or unreadable cache is
+// treated as empty
+func LoadHashCache(path string) *HashCache {
+	c := &HashCache{path: path, Entries: make(map[string]cachedHashes)}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, c)
+		if c.Entries == nil {
+			c.Entries = make(map[string]cachedHashes)
+		}
+	}
+	return c
+}
+
+// Get returns the cached checksums of a file if it has not changed
+func (c *HashCache) Get(path string) (Hashes, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Hashes{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.Entries[path]
+	if !ok || e.Size != info.Size() || e.ModTime != info.ModTime().UnixNano() {
+		return Hashes{}, false
+	}
+	return e.Hashes, true
+}
+
+// Put records the checksums of a file
+func (c *HashCache) Put(path string, h Hashes) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Entries[path] = cachedHashes{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Hashes: h}
+}
+
+// Save writes the cache, dropping entries for files that no longer exist
+func (c *HashCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for path := range c.Entries {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			delete(c.Entries, path)
+		}
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// VerifyStatus is the outcome of checking an image against a DAT
+type VerifyStatus string
+
+const (
+	// StatusVerified images match a DAT entry exactly
+	StatusVerified VerifyStatus = "verified"
+	// StatusUnknown images are not in the DAT, though a similar title may be
+	StatusUnknown VerifyStatus = "unknown"
+	// StatusBad images are known to the DAT by serial, or by title and
+	// size, but do not match it
+	StatusBad VerifyStatus = "bad"
+	// StatusError images could not be read
+	StatusError VerifyStatus = "error"
+)
+
+// Verification is the result for one image
+type Verification struct {
+	File     string       `json:"file"`
+	ID       string       `json:"id,omitempty"`
+	Status   VerifyStatus `json:"status"`
+	Hashes   *Hashes      `json:"hashes,omitempty"`
+	Match    string       `json:"match,omitempty"`
+	Expected *DATRom      `json:"expected,omitempty"`
+	Cached   bool         `json:"cached,omitempty"`
+	Note     string       `json:"note,omitempty"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// HashOptions tunes HashGames
+type HashOptions struct {
+	// Jobs is the number of images hashed in parallel
+	Jobs int
+	// Cache, if set, is consulted before hashing and updated after
+	Cache *HashCache
+	// Progress is called with the bytes hashed so far across all jobs and
+	// the total to hash. Calls are serialized
+	Progress func(done, total int64)
+}
+
+// HashResult holds the checksums of one image, or why it could not be read
+type HashResult struct {
+	Hashes Hashes
+	Cached bool
+	Err    error
+}
+
+// HashGames hashes every game with a pool of workers. Results are returned
+// in the order of games
+func HashGames(games []Game, opts HashOptions) []HashResult {
+	results := make([]HashResult, len(games))
+
+	// Cached images are resolved up front so progress only counts real work
+	var todo []int
+	var total int64
+	for i, g := range games {
+		if opts.Cache != nil {
+			if h, ok := opts.Cache.Get(g.Path); ok {
+				results[i] = HashResult{Hashes: h, Cached: true}
+				continue
+			}
+		}
+		todo = append(todo, i)
+		total += imageSize(g)
+	}
+
+	var mu sync.Mutex
+	var done int64
+	report := func(n int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		done += n
+		if opts.Progress != nil {
+			opts.Progress(done, total)
+		}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(opts.Jobs, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				g := games[i]
+				var last int64
+				h, err := HashImage(g, func(n int64) {
+					report(n - last)
+					last = n
+				})
+				if err != nil {
+					results[i] = HashResult{Err: err}
+					continue
+				}
+				if opts.Cache != nil {
+					opts.Cache.Put(g.Path, h)
+				}
+				results[i] = HashResult{Hashes: h}
+			}
+		}()
+	}
+
+	// Largest first keeps workers busy until the end
+	sort.SliceStable(todo, func(a, b int) bool { return games[todo[a]].Size > games[todo[b]].Size })
+	for _, i := range todo {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// Verify hashes every game and checks it against the DAT. Results are
+// returned in the order of games
+func Verify(games []Game, dat *DAT, opts HashOptions) []Verification {
+	results := make([]Verification, len(games))
+	for i, h := range HashGames(games, opts) {
+		g := games[i]
+		if h.Err != nil {
+			results[i] = Verification{File: g.RelPath(), ID: g.ID, Status: StatusError, Error: h.Err.Error()}
+			continue
+		}
+		results[i] = classify(g, h.Hashes, dat)
+		results[i].Cached = h.Cached
+	}
+	return results
+}
+
+func classify(g Game, h Hashes, dat *DAT) Verification {
+	v := Verification{File: g.RelPath(), ID: g.ID, Hashes: &h}
+	if game, _ := dat.Match(h); game != nil {
+		v.Status, v.Match = StatusVerified, game.Name
+		return v
+	}
+
+	if game, rom, sure := dat.Expected(g, h.Size); game != nil {
+		v.Match, v.Expected = game.Name, rom
+		// Redump lists CD games as raw BIN tracks, whose checksums an ISO
+		// made from them cannot have
+		if IsRawImage(rom.Name) {
+			v.Status = StatusUnknown
+			v.Note = "the DAT lists a BIN/CUE dump, which an ISO cannot match"
+			return v
+		}
+		// Another region or revision of the title, missing from the DAT
+		if !sure {
+			v.Status = StatusUnknown
+			v.Note = "same title; this region or revision may be missing from the DAT"
+			return v
+		}
+		v.Status = StatusBad
+		return v
+	}
+	v.Status = StatusUnknown
+	return v
+}
+
+// imageSize returns the number of bytes HashImage reads
+func imageSize(g Game) int64 {
+	if g.Compressed {
+		if z, err := OpenZSO(g.Path); err == nil {
+			defer z.Close()
+			return z.Size()
+		}
+	}
+	return g.Size
+}
+
+// Mismatch describes how a bad dump differs from the DAT
+func (v Verification) Mismatch() string {
+	if v.Status != StatusBad || v.Expected == nil || v.Hashes == nil {
+		return ""
+	}
+	if v.Expected.Size != v.Hashes.Size {
+		return fmt.Sprintf("size %d, expected %d", v.Hashes.Size, v.Expected.Size)
+	}
+	return fmt.Sprintf("SHA-1 %s, expected %s", v.Hashes.SHA1, v.Expected.SHA1)
+}
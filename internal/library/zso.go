@@ -0,0 +1,263 @@
+package library
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/matheusc457/ps2smb/internal/lz4"
+)
+
+// ZSO container layout, shared with ziso.py and OPL: a 24-byte header, an
+// index of block offsets and the LZ4 blocks themselves
+const (
+	zsoMagic      = "ZISO"
+	zsoHeaderSize = 24
+	zsoVersion    = 1
+	// zsoPlain marks an index entry whose block is stored uncompressed
+	zsoPlain = 0x80000000
+	// ZSOBlockSize is the block size OPL expects: one ISO sector
+	ZSOBlockSize = SectorSize
+)
+
+// IsZSO reports whether a file name has the ZSO extension
+func IsZSO(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".zso")
+}
+
+// ZSO reads a ZSO image as the ISO it compresses. It implements io.ReaderAt
+type ZSO struct {
+	f         readerAtCloser
+	total     int64
+	blockSize int64
+	align     uint
+	index     []uint32
+
+	// The last block read is cached, since reads rarely cover a whole block
+	cached int64
+	block  []byte
+	comp   []byte
+}
+
+// OpenZSO opens a ZSO image and loads its block index
+func OpenZSO(path string) (*ZSO, error) {
+	return openZSO(path, openFile)
+}
+
+// openZSO opens a ZSO with the given opener, openDirect to verify a file
+// just written
+func openZSO(path string, open func(string) (readerAtCloser, int64, error)) (*ZSO, error) {
+	f, size, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	z, err := readZSOHeader(f, size)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return z, nil
+}
+
+func readZSOHeader(f readerAtCloser, size int64) (*ZSO, error) {
+	header := make([]byte, zsoHeaderSize)
+	if _, err := f.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read ZSO header: %v", err)
+	}
+	if string(header[0:4]) != zsoMagic {
+		return nil, fmt.Errorf("not a ZSO image")
+	}
+
+	z := &ZSO{
+		f:         f,
+		total:     int64(binary.LittleEndian.Uint64(header[8:16])),
+		blockSize: int64(binary.LittleEndian.Uint32(header[16:20])),
+		align:     uint(header[21]),
+		cached:    -1,
+	}
+	if header[20] > zsoVersion {
+		return nil, fmt.Errorf("unsupported ZSO version %d", header[20])
+	}
+	if z.blockSize < SectorSize || z.blockSize&(z.blockSize-1) != 0 || z.align > 31 || z.total < 0 {
+		return nil, fmt.Errorf("invalid ZSO header")
+	}
+
+	blocks := (z.total + z.blockSize - 1) / z.blockSize
+	if (blocks+1)*4 > size {
+		return nil, fmt.Errorf("ZSO index is larger than the file")
+	}
+
+	raw := make([]byte, (blocks+1)*4)
+	if _, err := f.ReadAt(raw, zsoHeaderSize); err != nil {
+		return nil, fmt.Errorf("failed to read ZSO index: %v", err)
+	}
+	z.index = make([]uint32, blocks+1)
+	for i := range z.index {
+		z.index[i] = binary.LittleEndian.Uint32(raw[i*4:])
+	}
+
+	z.block = make([]byte, z.blockSize)
+	return z, nil
+}
+
+// Size returns the size of the uncompressed ISO
+func (z *ZSO) Size() int64 {
+	return z.total
+}
+
+// Close closes the image file
+func (z *ZSO) Close() error {
+	return z.f.Close()
+}
+
+// ReadAt reads uncompressed ISO bytes
+func (z *ZSO) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= z.total {
+			return n, io.EOF
+		}
+		block, err := z.readBlock(pos / z.blockSize)
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], block[pos%z.blockSize:])
+	}
+	return n, nil
+}
+
+// readBlock returns the uncompressed contents of block i
+func (z *ZSO) readBlock(i int64) ([]byte, error) {
+	size := min(z.blockSize, z.total-i*z.blockSize)
+	if i == z.cached {
+		return z.block[:size], nil
+	}
+
+	start := int64(z.index[i]&^zsoPlain) << z.align
+	end := int64(z.index[i+1]&^zsoPlain) << z.align
+	plain := z.index[i]&zsoPlain != 0
+	if plain {
+		// Alignment padding may follow a plain block; only size bytes count
+		end = start + size
+	}
+	if end < start || end-start > int64(lz4.CompressBound(int(z.blockSize)))+(1<<z.align) {
+		return nil, fmt.Errorf("block %d: invalid index entry", i)
+	}
+
+	if cap(z.comp) < int(end-start) {
+		z.comp = make([]byte, end-start)
+	}
+	comp := z.comp[:end-start]
+	if _, err := z.f.ReadAt(comp, start); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("block %d: %v", i, err)
+	}
+
+	z.cached = -1
+	if plain {
+		copy(z.block, comp)
+	} else {
+		// Decoding into an exact-size buffer skips any alignment padding
+		n, err := lz4.Decompress(z.block[:size], comp)
+		if err != nil || int64(n) != size {
+			return nil, fmt.Errorf("block %d: %v", i, lz4.ErrCorrupt)
+		}
+	}
+	z.cached = i
+	return z.block[:size], nil
+}
+
+// WriteZSO compresses size bytes of ISO data from r into a new ZSO file at
+// path and returns the SHA-1 of the uncompressed data. Blocks that LZ4
+// cannot shrink are stored plain, as ziso.py does
+func WriteZSO(path string, r io.ReaderAt, size int64, progress func(done, total int64)) (string, error) {
+	blocks := (size + ZSOBlockSize - 1) / ZSOBlockSize
+	indexSize := (blocks + 1) * 4
+
+	// Index entries hold offsets >> align in 31 bits; images over 2 GiB
+	// need a coarser alignment, paid for with padding between blocks
+	align := uint(0)
+	for (zsoHeaderSize+indexSize+size+blocks<<align)>>align > 0x7fffffff {
+		align++
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriterSize(f, copyBufferSize)
+	pos := int64(zsoHeaderSize + indexSize)
+	if _, err := w.Write(make([]byte, pos)); err != nil {
+		return "", err
+	}
+
+	h := sha1.New()
+	pw := &progressWriter{total: size, fn: progress}
+	in := bufio.NewReaderSize(io.NewSectionReader(r, 0, size), copyBufferSize)
+	index := make([]uint32, blocks+1)
+	block := make([]byte, ZSOBlockSize)
+	comp := make([]byte, 0, lz4.CompressBound(ZSOBlockSize))
+	padding := make([]byte, 1<<align)
+
+	for i := int64(0); i <= blocks; i++ {
+		if pad := -pos & (1<<align - 1); pad > 0 {
+			if _, err := w.Write(padding[:pad]); err != nil {
+				return "", err
+			}
+			pos += pad
+		}
+		index[i] = uint32(pos >> align)
+		if i == blocks {
+			break
+		}
+
+		n, err := io.ReadFull(in, block[:min(ZSOBlockSize, size-i*ZSOBlockSize)])
+		if err != nil {
+			return "", fmt.Errorf("failed to read block %d: %v", i, err)
+		}
+		h.Write(block[:n])
+		pw.Write(block[:n])
+
+		data := lz4.Compress(comp[:0], block[:n])
+		if len(data) >= n {
+			data = block[:n]
+			index[i] |= zsoPlain
+		}
+		if _, err := w.Write(data); err != nil {
+			return "", err
+		}
+		pos += int64(len(data))
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	header := make([]byte, zsoHeaderSize+indexSize)
+	copy(header, zsoMagic)
+	binary.LittleEndian.PutUint32(header[4:8], zsoHeaderSize)
+	binary.LittleEndian.PutUint64(header[8:16], uint64(size))
+	binary.LittleEndian.PutUint32(header[16:20], ZSOBlockSize)
+	header[20] = zsoVersion
+	header[21] = byte(align)
+	for i, v := range index {
+		binary.LittleEndian.PutUint32(header[zsoHeaderSize+i*4:], v)
+	}
+	if _, err := f.WriteAt(header, 0); err != nil {
+		return "", err
+	}
+	if err := f.Sync(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), f.Close()
+}
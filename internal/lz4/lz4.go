@@ -0,0 +1,172 @@
+// Package lz4 implements the LZ4 block format, as used inside ZSO images.
+// Only raw blocks are handled; the LZ4 frame format is not needed
+package lz4
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Block format limits (lz4_Block_format.md)
+const (
+	minMatch     = 4
+	lastLiterals = 5
+	mfLimit      = 12
+	maxOffset    = 65535
+	hashLog      = 12
+	runMask      = 15
+)
+
+// ErrCorrupt is returned for blocks that do not decode to the expected size
+var ErrCorrupt = fmt.Errorf("corrupt LZ4 block")
+
+// CompressBound returns the largest size Compress can produce for n bytes
+// of incompressible input
+func CompressBound(n int) int {
+	return n + n/255 + 16
+}
+
+// Compress encodes src as a single LZ4 block and appends it to dst. The
+// greedy single-probe match finder trades ratio for speed, like LZ4's
+// default level
+func Compress(dst, src []byte) []byte {
+	if len(src) < mfLimit+1 {
+		return appendLast(dst, src)
+	}
+
+	// Positions are stored plus one so the zero value means empty
+	var table [1 << hashLog]int32
+	anchor := 0
+	limit := len(src) - mfLimit
+	matchLimit := len(src) - lastLiterals
+
+	for i := 0; i < limit; {
+		seq := binary.LittleEndian.Uint32(src[i:])
+		h := (seq * 2654435761) >> (32 - hashLog)
+		ref := int(table[h]) - 1
+		table[h] = int32(i + 1)
+
+		if ref < 0 || i-ref > maxOffset || binary.LittleEndian.Uint32(src[ref:]) != seq {
+			i++
+			continue
+		}
+
+		for i > anchor && ref > 0 && src[i-1] == src[ref-1] {
+			i--
+			ref--
+		}
+		end := i + minMatch
+		for end < matchLimit && src[end] == src[ref+end-i] {
+			end++
+		}
+
+		dst = appendSequence(dst, src[anchor:i], i-ref, end-i)
+		i, anchor = end, end
+	}
+	return appendLast(dst, src[anchor:])
+}
+
+func appendSequence(dst, literals []byte, offset, matchLen int) []byte {
+	ml := matchLen - minMatch
+	dst = append(dst, byte(min(len(literals), runMask)<<4|min(ml, runMask)))
+	dst = appendLength(dst, len(literals))
+	dst = append(dst, literals...)
+	dst = append(dst, byte(offset), byte(offset>>8))
+	return appendLength(dst, ml)
+}
+
+// appendLast writes the final, literals-only sequence every block ends with
+func appendLast(dst, literals []byte) []byte {
+	dst = append(dst, byte(min(len(literals), runMask)<<4))
+	dst = appendLength(dst, len(literals))
+	return append(dst, literals...)
+}
+
+// appendLength writes the 255-run extension of a 4-bit length field
+func appendLength(dst []byte, n int) []byte {
+	if n < runMask {
+		return dst
+	}
+	for n -= runMask; n >= 255; n -= 255 {
+		dst = append(dst, 255)
+	}
+	return append(dst, byte(n))
+}
+
+// Decompress decodes one LZ4 block into dst and returns the number of
+// bytes written. Decoding stops once dst is full, so padding after the
+// block is ignored. Every offset and length is checked, so corrupt input
+// returns ErrCorrupt rather than reading or writing out of bounds
+func Decompress(dst, src []byte) (int, error) {
+	d, s := 0, 0
+	for s < len(src) {
+		token := src[s]
+		s++
+
+		lit := int(token >> 4)
+		if lit == runMask {
+			n, next, err := readLength(src, s)
+			if err != nil {
+				return d, err
+			}
+			lit, s = lit+n, next
+		}
+		if lit > len(src)-s || lit > len(dst)-d {
+			return d, ErrCorrupt
+		}
+		d += copy(dst[d:], src[s:s+lit])
+		s += lit
+		if s == len(src) || d == len(dst) {
+			return d, nil
+		}
+
+		if len(src)-s < 2 {
+			return d, ErrCorrupt
+		}
+		offset := int(src[s]) | int(src[s+1])<<8
+		s += 2
+		if offset == 0 || offset > d {
+			return d, ErrCorrupt
+		}
+
+		ml := int(token & runMask)
+		if ml == runMask {
+			n, next, err := readLength(src, s)
+			if err != nil {
+				return d, err
+			}
+			ml, s = ml+n, next
+		}
+		ml += minMatch
+		if ml > len(dst)-d {
+			return d, ErrCorrupt
+		}
+
+		if offset >= ml {
+			copy(dst[d:d+ml], dst[d-offset:])
+		} else {
+			// Overlapping match: repeats the last offset bytes
+			for i := 0; i < ml; i++ {
+				dst[d+i] = dst[d-offset+i]
+			}
+		}
+		d += ml
+	}
+	// A block always ends with literals
+	return d, ErrCorrupt
+}
+
+func readLength(src []byte, s int) (int, int, error) {
+	n := 0
+	for {
+		if s >= len(src) || n > len(src)*255 {
+			return 0, s, ErrCorrupt
+		}
+		b := src[s]
+		s++
+		n += int(b)
+		if b != 255 {
+			return n, s, nil
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package netbios
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/matheusc457/ps2smb/internal/samba"
+)
+
+// MaxNameLength is the longest NetBIOS name; the 16th byte is the suffix
+const MaxNameLength = 15
+
+// invalidChars may not appear in a NetBIOS computer name. Dots and spaces
+// are technically allowed but break OPL's name lookup and DNS fallbacks
+const invalidChars = `\/:*?"<>|.; ,=+[]`
+
+// ValidateName checks a NetBIOS computer or workgroup name
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name is empty")
+	}
+	if len(name) > MaxNameLength {
+		return fmt.Errorf("%q is %d characters long; NetBIOS names are limited to %d", name, len(name), MaxNameLength)
+	}
+	for _, r := range name {
+		if r < 0x21 || r > 0x7e {
+			return fmt.Errorf("%q contains a non-printable or non-ASCII character", name)
+		}
+		if strings.ContainsRune(invalidChars, r) {
+			return fmt.Errorf("%q contains the invalid character %q", name, r)
+		}
+	}
+	return nil
+}
+
+// Sanitize turns an arbitrary string into a valid NetBIOS name by
+// upper-casing it, replacing invalid characters and truncating it
+func Sanitize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if r < 0x21 || r > 0x7e || strings.ContainsRune(invalidChars, r) {
+			r = '-'
+		}
+		b.WriteRune(r)
+	}
+	out := strings.Trim(b.String(), "-")
+	if len(out) > MaxNameLength {
+		out = strings.TrimRight(out[:MaxNameLength], "-")
+	}
+	return out
+}
+
+// FromHostname derives the name Samba uses by default: the first label of
+// the hostname in upper case. The result is not validated
+func FromHostname(hostname string) string {
+	label, _, _ := strings.Cut(strings.TrimSpace(hostname), ".")
+	return strings.ToUpper(label)
+}
+
+// Identity is the NetBIOS name and workgroup the server announces
+type Identity struct {
+	Name       string `json:"name"`
+	Configured bool   `json:"configured"`
+	Workgroup  string `json:"workgroup"`
+}
+
+// ServerIdentity reads 'netbios name' and 'workgroup' from [global],
+// falling back to Samba's defaults (hostname and WORKGROUP)
+func ServerIdentity() (*Identity, error) {
+	id := &Identity{Workgroup: "WORKGROUP"}
+
+	if conf, err := samba.LoadConfig(); err == nil {
+		if global := conf.Section("global"); global != nil {
+			if v, ok := global.Get("netbios name"); ok && v != "" {
+				id.Name = strings.ToUpper(v)
+				id.Configured = true
+			}
+			if v, ok := global.Get("workgroup"); ok && v != "" {
+				id.Workgroup = strings.ToUpper(v)
+			}
+		}
+	}
+
+	if id.Name == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hostname: %v", err)
+		}
+		id.Name = FromHostname(hostname)
+	}
+	return id, nil
+}
+
+// EncodeName applies RFC 1001 first-level encoding to a name padded to 15
+// characters plus the given suffix byte
+func EncodeName(name string, suffix byte) []byte {
+	raw := make([]byte, 16)
+	padded := fmt.Sprintf("%-15s", strings.ToUpper(name))
+	copy(raw, padded[:15])
+	raw[15] = suffix
+
+	out := make([]byte, 0, 34)
+	out = append(out, 32)
+	for _, b := range raw {
+		out = append(out, 'A'+(b>>4), 'A'+(b&0x0f))
+	}
+	return append(out, 0)
+}
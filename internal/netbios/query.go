@@ -0,0 +1,162 @@
+package netbios
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+)
+
+// NameServicePort is the NetBIOS name service UDP port
+const NameServicePort = 137
+
+// Name suffixes registered by nmbd
+const (
+	SuffixWorkstation = 0x00
+	SuffixServer      = 0x20
+)
+
+// NBNS header flags
+const (
+	flagResponse   = 0x8000
+	flagRecursion  = 0x0100
+	flagBroadcast  = 0x0010
+	rcodeMask      = 0x000f
+	typeNB         = 0x0020
+	classIN        = 0x0001
+	nbEntrySize    = 6
+	nbHeaderLength = 12
+)
+
+// Answer is one node that responded to a name query
+type Answer struct {
+	From      net.IP   `json:"from"`
+	Addresses []net.IP `json:"addresses"`
+}
+
+// Query asks who owns name<suffix> and collects every answer received
+// within timeout. Broadcast queries go to a subnet broadcast address, the
+// way OPL resolves a server name on the LAN; unicast ones ask a single host
+func Query(name string, suffix byte, dest net.IP, broadcast bool, timeout time.Duration) ([]Answer, error) {
+	lc := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			var serr error
+			err := c.Control(func(fd uintptr) {
+				serr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_BROADCAST, 1)
+			})
+			if err != nil {
+				return err
+			}
+			return serr
+		},
+	}
+	conn, err := lc.ListenPacket(context.Background(), "udp4", ":0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open UDP socket: %v", err)
+	}
+	defer conn.Close()
+
+	id := uint16(rand.UintN(0x10000))
+	flags := uint16(flagRecursion)
+	if broadcast {
+		flags |= flagBroadcast
+	}
+
+	req := binary.BigEndian.AppendUint16(nil, id)
+	req = binary.BigEndian.AppendUint16(req, flags)
+	req = binary.BigEndian.AppendUint16(req, 1) // questions
+	req = append(req, 0, 0, 0, 0, 0, 0)
+	req = append(req, EncodeName(name, suffix)...)
+	req = binary.BigEndian.AppendUint16(req, typeNB)
+	req = binary.BigEndian.AppendUint16(req, classIN)
+
+	if _, err := conn.WriteTo(req, &net.UDPAddr{IP: dest, Port: NameServicePort}); err != nil {
+		return nil, fmt.Errorf("failed to send name query: %v", err)
+	}
+
+	var answers []Answer
+	var negative error
+	deadline := time.Now().Add(timeout)
+	buf := make([]byte, 1500)
+	for {
+		conn.SetReadDeadline(deadline)
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			break
+		}
+
+		addrs, err := parseQueryResponse(buf[:n], id)
+		if err != nil {
+			negative = err
+			continue
+		}
+		if addrs == nil {
+			continue
+		}
+		answers = append(answers, Answer{From: from.(*net.UDPAddr).IP, Addresses: addrs})
+		if !broadcast {
+			break
+		}
+	}
+
+	if len(answers) == 0 {
+		if negative != nil {
+			return nil, negative
+		}
+		return nil, fmt.Errorf("no answer for %s<%02x> from %s within %s", name, suffix, dest, timeout)
+	}
+	return answers, nil
+}
+
+// parseQueryResponse returns the addresses in a positive name query
+// response, nil for unrelated packets, or an error for negative responses
+func parseQueryResponse(b []byte, id uint16) ([]net.IP, error) {
+	if len(b) < nbHeaderLength || binary.BigEndian.Uint16(b[0:2]) != id {
+		return nil, nil
+	}
+	flags := binary.BigEndian.Uint16(b[2:4])
+	if flags&flagResponse == 0 {
+		return nil, nil
+	}
+	if rcode := flags & rcodeMask; rcode != 0 {
+		return nil, fmt.Errorf("negative name query response (rcode %d)", rcode)
+	}
+	if binary.BigEndian.Uint16(b[6:8]) == 0 {
+		return nil, nil
+	}
+
+	off := skipName(b, nbHeaderLength)
+	if off < 0 || off+10 > len(b) {
+		return nil, nil
+	}
+	rrType := binary.BigEndian.Uint16(b[off : off+2])
+	rdLen := int(binary.BigEndian.Uint16(b[off+8 : off+10]))
+	off += 10
+	if rrType != typeNB || off+rdLen > len(b) {
+		return nil, nil
+	}
+
+	var addrs []net.IP
+	for i := off; i+nbEntrySize <= off+rdLen; i += nbEntrySize {
+		addrs = append(addrs, net.IPv4(b[i+2], b[i+3], b[i+4], b[i+5]))
+	}
+	return addrs, nil
+}
+
+// skipName returns the offset just past an encoded name, or -1
+func skipName(b []byte, off int) int {
+	for off < len(b) {
+		l := int(b[off])
+		switch {
+		case l == 0:
+			return off + 1
+		case l&0xc0 == 0xc0:
+			return off + 2
+		}
+		off += 1 + l
+	}
+	return -1
+}
@@ -0,0 +1,247 @@
+package netconf
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/matheusc457/ps2smb/internal/network"
+)
+
+// Backend is the network configuration daemon a profile is written for
+type Backend string
+
+const (
+	BackendNetworkManager Backend = "networkmanager"
+	BackendNetworkd       Backend = "networkd"
+)
+
+// Addressing modes for the PS2 link
+const (
+	// ModeShared hands out addresses to the PS2 over DHCP
+	ModeShared = "shared"
+	// ModeManual only assigns the static address to this PC
+	ModeManual = "manual"
+)
+
+// Directories the daemons read persistent profiles from
+var (
+	nmConnectionDir = "/etc/NetworkManager/system-connections"
+	networkdDir     = "/etc/systemd/network"
+)
+
+// Profile is a generated configuration file for the PS2 link
+type Profile struct {
+	Backend Backend
+	Path    string
+	Content string
+	Mode    os.FileMode
+}
+
+// DetectBackend returns the active network configuration daemon
+func DetectBackend() (Backend, error) {
+	if isActive("NetworkManager") {
+		return BackendNetworkManager, nil
+	}
+	if isActive("systemd-networkd") {
+		return BackendNetworkd, nil
+	}
+	return "", fmt.Errorf("neither NetworkManager nor systemd-networkd is running")
+}
+
+// ParseBackend validates a backend name given on the command line
+func ParseBackend(name string) (Backend, error) {
+	switch strings.ToLower(name) {
+	case "", "auto":
+		return DetectBackend()
+	case "networkmanager", "nm":
+		return BackendNetworkManager, nil
+	case "networkd", "systemd-networkd":
+		return BackendNetworkd, nil
+	}
+	return "", fmt.Errorf("unknown backend %q (use auto, networkmanager or networkd)", name)
+}
+
+// Generate builds a profile giving this PC the subnet's address on iface
+func Generate(backend Backend, iface string, subnet *network.Subnet, mode string) (*Profile, error) {
+	if mode != ModeShared && mode != ModeManual {
+		return nil, fmt.Errorf("unknown mode %q (use shared or manual)", mode)
+	}
+
+	switch backend {
+	case BackendNetworkManager:
+		return generateNM(iface, subnet, mode)
+	case BackendNetworkd:
+		return generateNetworkd(iface, subnet, mode)
+	}
+	return nil, fmt.Errorf("unsupported backend %q", backend)
+}
+
+func generateNM(iface string, subnet *network.Subnet, mode string) (*Profile, error) {
+	id := "ps2smb-" + iface
+	path := filepath.Join(nmConnectionDir, id+".nmconnection")
+
+	// Keep the UUID stable across regenerations so NetworkManager sees an update
+	uuid := existingNMUUID(path)
+	if uuid == "" {
+		var err error
+		if uuid, err = newUUID(); err != nil {
+			return nil, err
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by ps2smb for the PS2 link on %s\n", iface)
+	b.WriteString("[connection]\n")
+	fmt.Fprintf(&b, "id=%s\n", id)
+	fmt.Fprintf(&b, "uuid=%s\n", uuid)
+	b.WriteString("type=ethernet\n")
+	fmt.Fprintf(&b, "interface-name=%s\n", iface)
+	b.WriteString("autoconnect=true\n")
+	b.WriteString("\n[ethernet]\n")
+	b.WriteString("\n[ipv4]\n")
+	fmt.Fprintf(&b, "method=%s\n", mode)
+	fmt.Fprintf(&b, "address1=%s/%d\n", subnet.IP, subnet.PrefixLen)
+	b.WriteString("never-default=true\n")
+	b.WriteString("\n[ipv6]\n")
+	b.WriteString("method=disabled\n")
+
+	// NetworkManager ignores keyfiles readable by other users
+	return &Profile{Backend: BackendNetworkManager, Path: path, Content: b.String(), Mode: 0600}, nil
+}
+
+func generateNetworkd(iface string, subnet *network.Subnet, mode string) (*Profile, error) {
+	path := filepath.Join(networkdDir, "50-ps2smb-"+iface+".network")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by ps2smb for the PS2 link on %s\n", iface)
+	b.WriteString("[Match]\n")
+	fmt.Fprintf(&b, "Name=%s\n", iface)
+	b.WriteString("\n[Network]\n")
+	fmt.Fprintf(&b, "Address=%s/%d\n", subnet.IP, subnet.PrefixLen)
+	b.WriteString("ConfigureWithoutCarrier=yes\n")
+	b.WriteString("LinkLocalAddressing=no\n")
+
+	if mode == ModeShared {
+		start, _, err := subnet.Pool(10)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString("DHCPServer=yes\n")
+		b.WriteString("\n[DHCPServer]\n")
+		fmt.Fprintf(&b, "PoolOffset=%d\n", hostOffset(subnet, start))
+		b.WriteString("PoolSize=10\n")
+		b.WriteString("EmitDNS=no\n")
+	}
+
+	return &Profile{Backend: BackendNetworkd, Path: path, Content: b.String(), Mode: 0644}, nil
+}
+
+// Existing returns the current content of the profile path, or "" if absent
+func (p *Profile) Existing() string {
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Diff returns a line diff between the installed file and the new profile
+func (p *Profile) Diff() string {
+	return Diff(p.Existing(), p.Content)
+}
+
+// Install writes the profile. Activate applies it
+func (p *Profile) Install() error {
+	if err := os.MkdirAll(filepath.Dir(p.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(p.Path), err)
+	}
+	if err := os.WriteFile(p.Path, []byte(p.Content), p.Mode); err != nil {
+		return fmt.Errorf("failed to write %s: %v", p.Path, err)
+	}
+	if err := os.Chmod(p.Path, p.Mode); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %v", p.Path, err)
+	}
+	return nil
+}
+
+// Activate asks the daemon to apply an installed profile. NetworkManager
+// cannot bring a connection up while the cable is unplugged, which is
+// common for a direct link; the profile then applies once it is plugged in
+func (p *Profile) Activate() error {
+	return reload(p.Backend, p.Path)
+}
+
+// Remove deletes an installed profile and reloads the daemon that owns it
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %v", path, err)
+	}
+
+	backend := BackendNetworkd
+	if strings.HasPrefix(path, nmConnectionDir) {
+		backend = BackendNetworkManager
+	}
+	return reload(backend, "")
+}
+
+func reload(backend Backend, path string) error {
+	switch backend {
+	case BackendNetworkManager:
+		if err := run("nmcli", "connection", "reload"); err != nil {
+			return err
+		}
+		if path != "" {
+			id := strings.TrimSuffix(filepath.Base(path), ".nmconnection")
+			return run("nmcli", "connection", "up", "id", id)
+		}
+	case BackendNetworkd:
+		return run("networkctl", "reload")
+	}
+	return nil
+}
+
+func isActive(unit string) bool {
+	return exec.Command("systemctl", "is-active", "--quiet", unit).Run() == nil
+}
+
+func run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %v: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func existingNMUUID(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "uuid="); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate UUID: %v", err)
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// hostOffset is the distance of ip from the subnet's network address
+func hostOffset(subnet *network.Subnet, ip net.IP) int {
+	a, b := subnet.Network.To4(), ip.To4()
+	return int(b[0]-a[0])<<24 | int(b[1]-a[1])<<16 | int(b[2]-a[2])<<8 | int(b[3]-a[3])
+}
@@ -0,0 +1,180 @@
+package network
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sysClassNet is where the kernel exposes per-interface link details
+var sysClassNet = "/sys/class/net"
+
+// Kind classifies the hardware behind a network interface
+type Kind string
+
+const (
+	KindWired    Kind = "wired"
+	KindWireless Kind = "wireless"
+	KindBridge   Kind = "bridge"
+	KindVirtual  Kind = "virtual"
+	KindLoopback Kind = "loopback"
+)
+
+// Address is an IP address with its prefix length
+type Address struct {
+	IP        net.IP
+	PrefixLen int
+}
+
+// String returns the address in CIDR notation
+func (a Address) String() string {
+	return a.IP.String() + "/" + strconv.Itoa(a.PrefixLen)
+}
+
+// MarshalText encodes the address in CIDR notation
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// IsIPv4 reports whether the address is an IPv4 address
+func (a Address) IsIPv4() bool {
+	return a.IP.To4() != nil
+}
+
+// Interface describes a network interface and its link state
+type Interface struct {
+	Name      string    `json:"name"`
+	Index     int       `json:"index"`
+	MAC       string    `json:"mac,omitempty"`
+	Kind      Kind      `json:"kind"`
+	Addresses []Address `json:"addresses"`
+	MTU       int       `json:"mtu"`
+	OperState string    `json:"operstate"`
+	Carrier   bool      `json:"carrier"`
+	SpeedMbps int       `json:"speed_mbps,omitempty"`
+	Duplex    string    `json:"duplex,omitempty"`
+	Flags     []string  `json:"flags"`
+}
+
+// Up reports whether the interface is administratively up
+func (i Interface) Up() bool {
+	for _, f := range i.Flags {
+		if f == "up" {
+			return true
+		}
+	}
+	return false
+}
+
+// IPv4 returns the interface's IPv4 addresses
+func (i Interface) IPv4() []Address {
+	var addrs []Address
+	for _, a := range i.Addresses {
+		if a.IsIPv4() {
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs
+}
+
+// ListInterfaces returns all non-loopback interfaces sorted by name
+func ListInterfaces() ([]Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Interface{}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		result = append(result, describe(iface))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// GetInterface returns details for a single interface
+func GetInterface(name string) (*Interface, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	info := describe(*iface)
+	return &info, nil
+}
+
+func describe(iface net.Interface) Interface {
+	info := Interface{
+		Name:      iface.Name,
+		Index:     iface.Index,
+		MAC:       iface.HardwareAddr.String(),
+		MTU:       iface.MTU,
+		Addresses: []Address{},
+		Flags:     strings.Split(iface.Flags.String(), "|"),
+		Kind:      kindOf(iface),
+		OperState: readSys(iface.Name, "operstate"),
+		Carrier:   readSys(iface.Name, "carrier") == "1",
+		Duplex:    readSys(iface.Name, "duplex"),
+	}
+
+	if info.Duplex == "unknown" {
+		info.Duplex = ""
+	}
+	// speed reads -1 or fails with EINVAL when the link is down or virtual
+	if speed, err := strconv.Atoi(readSys(iface.Name, "speed")); err == nil && speed > 0 {
+		info.SpeedMbps = speed
+	}
+
+	if addrs, err := iface.Addrs(); err == nil {
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok {
+				ones, _ := ipnet.Mask.Size()
+				info.Addresses = append(info.Addresses, Address{IP: ipnet.IP, PrefixLen: ones})
+			}
+		}
+	}
+
+	return info
+}
+
+func kindOf(iface net.Interface) Kind {
+	if iface.Flags&net.FlagLoopback != 0 {
+		return KindLoopback
+	}
+	if exists(iface.Name, "wireless") || exists(iface.Name, "phy80211") {
+		return KindWireless
+	}
+	if exists(iface.Name, "bridge") {
+		return KindBridge
+	}
+	// Physical NICs have a backing device; veth, tun, docker0 etc. do not
+	if !exists(iface.Name, "device") {
+		return KindVirtual
+	}
+	// ARPHRD_ETHER; anything else (e.g. tun, ppp) is not a usable PS2 link
+	if readSys(iface.Name, "type") != "1" {
+		return KindVirtual
+	}
+	return KindWired
+}
+
+func readSys(name, attr string) string {
+	data, err := os.ReadFile(filepath.Join(sysClassNet, name, attr))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func exists(name, attr string) bool {
+	_, err := os.Stat(filepath.Join(sysClassNet, name, attr))
+	return err == nil
+}
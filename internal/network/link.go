@@ -0,0 +1,48 @@
+package network
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// HasAddress reports whether the interface already carries the CIDR address
+func HasAddress(ifaceName, cidr string) bool {
+	iface, err := GetInterface(ifaceName)
+	if err != nil {
+		return false
+	}
+	for _, addr := range iface.Addresses {
+		if addr.String() == cidr {
+			return true
+		}
+	}
+	return false
+}
+
+// AddAddress assigns a CIDR address to an interface and brings the link up
+func AddAddress(ifaceName, cidr string) error {
+	if err := ipCommand("addr", "add", cidr, "dev", ifaceName); err != nil {
+		return fmt.Errorf("failed to add %s to %s: %v", cidr, ifaceName, err)
+	}
+	if err := ipCommand("link", "set", ifaceName, "up"); err != nil {
+		return fmt.Errorf("failed to bring up %s: %v", ifaceName, err)
+	}
+	return nil
+}
+
+// DelAddress removes a CIDR address from an interface
+func DelAddress(ifaceName, cidr string) error {
+	if err := ipCommand("addr", "del", cidr, "dev", ifaceName); err != nil {
+		return fmt.Errorf("failed to remove %s from %s: %v", cidr, ifaceName, err)
+	}
+	return nil
+}
+
+func ipCommand(args ...string) error {
+	out, err := exec.Command("ip", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -5,22 +5,13 @@ import (
 	"net"
 )
 
-// GetLocalIP returns the primary local IP address
+// GetLocalIP returns the IPv4 address of the interface most likely to face the PS2
 func GetLocalIP() (string, error) {
-	addrs, err := net.InterfaceAddrs()
+	best, _, err := SelectPrimary()
 	if err != nil {
 		return "", err
 	}
-
-	for _, addr := range addrs {
-		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
-			if ipnet.IP.To4() != nil {
-				return ipnet.IP.String(), nil
-			}
-		}
-	}
-
-	return "", fmt.Errorf("no local IP address found")
+	return best.Address.IP.String(), nil
 }
 
 // GetIPFromInterface returns the IP address of a specific interface
@@ -46,75 +37,6 @@ func GetIPFromInterface(ifaceName string) (string, error) {
 	return "", fmt.Errorf("no IPv4 address found on interface %s", ifaceName)
 }
 
-// ListInterfaces returns a list of all network interfaces with their IPs
-func ListInterfaces() (map[string]string, error) {
-	interfaces := make(map[string]string)
-	
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		return nil, err
-	}
-
-	for _, iface := range ifaces {
-		// Skip loopback and down interfaces
-		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
-			continue
-		}
-
-		addrs, err := iface.Addrs()
-		if err != nil {
-			continue
-		}
-
-		for _, addr := range addrs {
-			if ipnet, ok := addr.(*net.IPNet); ok {
-				if ipnet.IP.To4() != nil {
-					interfaces[iface.Name] = ipnet.IP.String()
-					break
-				}
-			}
-		}
-	}
-
-	return interfaces, nil
-}
-
-// GetAllLocalIPs returns all local IP addresses with interface names
-func GetAllLocalIPs() ([]string, error) {
-	var ips []string
-	
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		return nil, err
-	}
-
-	for _, iface := range ifaces {
-		// Skip loopback and down interfaces
-		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
-			continue
-		}
-
-		addrs, err := iface.Addrs()
-		if err != nil {
-			continue
-		}
-
-		for _, addr := range addrs {
-			if ipnet, ok := addr.(*net.IPNet); ok {
-				if ipnet.IP.To4() != nil {
-					ips = append(ips, fmt.Sprintf("%s (%s)", ipnet.IP.String(), iface.Name))
-				}
-			}
-		}
-	}
-
-	if len(ips) == 0 {
-		return nil, fmt.Errorf("no network interfaces found")
-	}
-
-	return ips, nil
-}
-
 // FormatSMBPath formats the SMB path for Windows/PS2 style
 func FormatSMBPath(ip, shareName string) string {
 	return fmt.Sprintf("\\\\%s\\%s", ip, shareName)
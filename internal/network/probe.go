@@ -0,0 +1,291 @@
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// procNetARP is the kernel's IPv4 neighbour table
+var procNetARP = "/proc/net/arp"
+
+// ATF_COM marks a completed (resolved) ARP entry
+const arpFlagComplete = 0x2
+
+// probePorts are common TCP ports; a refused connection still proves a host exists
+var probePorts = []int{445, 139, 80, 443, 22, 62078}
+
+// probeBatch is how many candidates are probed concurrently
+const probeBatch = 8
+
+// ARPEntry is a single row of /proc/net/arp
+type ARPEntry struct {
+	IP       net.IP
+	HWAddr   string
+	Device   string
+	Complete bool
+}
+
+// ProbeResult records whether a candidate address appears to be in use
+type ProbeResult struct {
+	IP       net.IP `json:"ip"`
+	InUse    bool   `json:"in_use"`
+	Evidence string `json:"evidence"`
+}
+
+// ReadARPTable parses the kernel ARP cache
+func ReadARPTable() ([]ARPEntry, error) {
+	f, err := os.Open(procNetARP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ARP table: %v", err)
+	}
+	defer f.Close()
+
+	var entries []ARPEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		flags, err := strconv.ParseInt(strings.TrimPrefix(fields[2], "0x"), 16, 32)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, ARPEntry{
+			IP:       net.ParseIP(fields[0]),
+			HWAddr:   fields[3],
+			Device:   fields[5],
+			Complete: flags&arpFlagComplete != 0 && fields[3] != "00:00:00:00:00:00",
+		})
+	}
+
+	return entries, scanner.Err()
+}
+
+// FindFreeIP probes the subnet's candidate addresses and returns the first
+// one that shows no sign of life. This is best-effort: a device that is off,
+// asleep or firewalled will not answer and can still claim the address later.
+func FindFreeIP(subnet *Subnet, timeout time.Duration, limit int) (net.IP, []ProbeResult, error) {
+	candidates := subnet.Candidates()
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	var results []ProbeResult
+	for start := 0; start < len(candidates); start += probeBatch {
+		end := start + probeBatch
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+
+		batch := probeAll(candidates[start:end], subnet.Interface, timeout)
+		results = append(results, batch...)
+		for _, r := range batch {
+			if !r.InUse {
+				return r.IP, results, nil
+			}
+		}
+	}
+
+	return nil, results, fmt.Errorf("all %d probed addresses in %s appear to be in use", len(results), subnet)
+}
+
+// ProbeAddress checks a single address for signs of an existing device
+func ProbeAddress(ip net.IP, device string, timeout time.Duration) ProbeResult {
+	return probeAll([]net.IP{ip}, device, timeout)[0]
+}
+
+func probeAll(ips []net.IP, device string, timeout time.Duration) []ProbeResult {
+	results := make([]ProbeResult, len(ips))
+
+	var wg sync.WaitGroup
+	for i, ip := range ips {
+		results[i].IP = ip
+		if entry := lookupARP(ip, device); entry != nil {
+			results[i].InUse = true
+			results[i].Evidence = fmt.Sprintf("ARP entry %s", entry.HWAddr)
+			continue
+		}
+
+		wg.Add(1)
+		go func(r *ProbeResult) {
+			defer wg.Done()
+			r.InUse, r.Evidence = probeHost(r.IP, timeout)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	// Probes trigger ARP resolution even when the host drops every packet
+	for i := range results {
+		if results[i].InUse {
+			continue
+		}
+		if entry := lookupARP(results[i].IP, device); entry != nil {
+			results[i].InUse = true
+			results[i].Evidence = fmt.Sprintf("answered ARP as %s", entry.HWAddr)
+		}
+	}
+
+	return results
+}
+
+func lookupARP(ip net.IP, device string) *ARPEntry {
+	entries, err := ReadARPTable()
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		if e.Complete && e.IP.Equal(ip) && (device == "" || e.Device == device) {
+			return &e
+		}
+	}
+	return nil
+}
+
+func probeHost(ip net.IP, timeout time.Duration) (bool, string) {
+	if ok, err := pingICMP(ip, timeout); err == nil && ok {
+		return true, "answered ICMP echo"
+	}
+
+	evidence := make(chan string, len(probePorts))
+	var wg sync.WaitGroup
+	for _, port := range probePorts {
+		wg.Add(1)
+		go func(port int) {
+			defer wg.Done()
+			addr := net.JoinHostPort(ip.String(), strconv.Itoa(port))
+			conn, err := net.DialTimeout("tcp", addr, timeout)
+			if err == nil {
+				conn.Close()
+				evidence <- fmt.Sprintf("TCP port %d open", port)
+			} else if errors.Is(err, syscall.ECONNREFUSED) {
+				evidence <- fmt.Sprintf("TCP port %d refused", port)
+			}
+		}(port)
+	}
+	wg.Wait()
+	close(evidence)
+
+	if e, ok := <-evidence; ok {
+		return true, e
+	}
+	return false, "no response"
+}
+
+// pingICMP sends one echo request over a raw socket; it needs root or
+// CAP_NET_RAW and returns an error when ICMP cannot be used
+func pingICMP(ip net.IP, timeout time.Duration) (bool, error) {
+	conn, err := net.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	id := uint16(os.Getpid() & 0xffff)
+	seq := uint16(time.Now().UnixNano() & 0xffff)
+
+	msg := make([]byte, 8+16)
+	msg[0] = 8 // echo request
+	binary.BigEndian.PutUint16(msg[4:], id)
+	binary.BigEndian.PutUint16(msg[6:], seq)
+	copy(msg[8:], "ps2smb-probe....")
+	binary.BigEndian.PutUint16(msg[2:], icmpChecksum(msg))
+
+	if _, err := conn.WriteTo(msg, &net.IPAddr{IP: ip}); err != nil {
+		return false, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	conn.SetReadDeadline(deadline)
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return false, nil
+		}
+		addr, ok := peer.(*net.IPAddr)
+		if !ok || !addr.IP.Equal(ip) || n < 8 {
+			continue
+		}
+		// echo reply with our identifier
+		if buf[0] == 0 && binary.BigEndian.Uint16(buf[4:]) == id {
+			return true, nil
+		}
+	}
+}
+
+func icmpChecksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// LookupMAC returns the IPv4 address the ARP table holds for a MAC address,
+// optionally restricted to one interface
+func LookupMAC(mac net.HardwareAddr, device string) (net.IP, error) {
+	entries, err := ReadARPTable()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if !e.Complete || (device != "" && e.Device != device) {
+			continue
+		}
+		if hw, err := net.ParseMAC(e.HWAddr); err == nil && hw.String() == mac.String() {
+			return e.IP, nil
+		}
+	}
+	return nil, fmt.Errorf("%s not found in the ARP table; make sure the PS2 is connected", mac)
+}
+
+// PortState is the result of a TCP connection attempt
+type PortState string
+
+const (
+	PortOpen     PortState = "open"
+	PortRefused  PortState = "refused"
+	PortTimeout  PortState = "timeout"
+	PortFiltered PortState = "filtered"
+)
+
+// ProbePort attempts a TCP connection and classifies the outcome. A timeout
+// usually means a firewall silently drops packets, while "filtered" means an
+// ICMP unreachable or a local firewall reject was received.
+func ProbePort(ip net.IP, port int, timeout time.Duration) (PortState, error) {
+	addr := net.JoinHostPort(ip.String(), strconv.Itoa(port))
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err == nil {
+		conn.Close()
+		return PortOpen, nil
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return PortRefused, err
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return PortTimeout, err
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH),
+		errors.Is(err, syscall.EACCES), errors.Is(err, syscall.EPERM):
+		return PortFiltered, err
+	}
+	return PortFiltered, err
+}
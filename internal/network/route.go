@@ -0,0 +1,135 @@
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// procNetRoute is the kernel's IPv4 routing table
+var procNetRoute = "/proc/net/route"
+
+// Route flags from linux/route.h
+const (
+	routeFlagUp      = 0x1
+	routeFlagGateway = 0x2
+)
+
+// Route is a single entry of the IPv4 routing table
+type Route struct {
+	Iface       string
+	Destination net.IP
+	Gateway     net.IP
+	Mask        net.IPMask
+	Flags       int
+	Metric      int
+}
+
+// IsDefault reports whether the route is a default route (0.0.0.0/0)
+func (r Route) IsDefault() bool {
+	ones, _ := r.Mask.Size()
+	return r.Destination.Equal(net.IPv4zero) && ones == 0
+}
+
+// HasGateway reports whether the route goes through a gateway
+func (r Route) HasGateway() bool {
+	return r.Flags&routeFlagGateway != 0
+}
+
+// ReadRoutes parses the IPv4 routing table from /proc/net/route
+func ReadRoutes() ([]Route, error) {
+	f, err := os.Open(procNetRoute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routing table: %v", err)
+	}
+	defer f.Close()
+
+	var routes []Route
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+
+		dest, err1 := parseHexIP(fields[1])
+		gw, err2 := parseHexIP(fields[2])
+		mask, err3 := parseHexIP(fields[7])
+		flags, err4 := strconv.ParseInt(fields[3], 16, 32)
+		metric, err5 := strconv.Atoi(fields[6])
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil || err5 != nil {
+			continue
+		}
+		if flags&routeFlagUp == 0 {
+			continue
+		}
+
+		routes = append(routes, Route{
+			Iface:       fields[0],
+			Destination: dest,
+			Gateway:     gw,
+			Mask:        net.IPMask(mask.To4()),
+			Flags:       int(flags),
+			Metric:      metric,
+		})
+	}
+
+	return routes, scanner.Err()
+}
+
+// DefaultRoute returns the default route with the lowest metric
+func DefaultRoute() (*Route, error) {
+	routes, err := ReadRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	var best *Route
+	for i := range routes {
+		r := &routes[i]
+		if r.IsDefault() && (best == nil || r.Metric < best.Metric) {
+			best = r
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no default route found")
+	}
+	return best, nil
+}
+
+// GatewayFor returns the default gateway reachable through an interface
+func GatewayFor(iface string) (net.IP, error) {
+	routes, err := ReadRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	var best *Route
+	for i := range routes {
+		r := &routes[i]
+		if r.Iface == iface && r.IsDefault() && r.HasGateway() && (best == nil || r.Metric < best.Metric) {
+			best = r
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no gateway found on interface %s", iface)
+	}
+	return best.Gateway, nil
+}
+
+// parseHexIP decodes the little-endian hex address format used by /proc/net/route
+func parseHexIP(s string) (net.IP, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 4 {
+		return nil, fmt.Errorf("invalid address %q", s)
+	}
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(b))
+	return ip, nil
+}
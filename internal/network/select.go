@@ -0,0 +1,144 @@
+package network
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// excludedPrefixes names container, VPN and virtualization interfaces that
+// are never the link a PS2 is plugged into
+var excludedPrefixes = []string{
+	"docker", "br-", "veth", "virbr", "vnet", "vmnet", "vboxnet",
+	"lxcbr", "lxdbr", "podman", "cni", "flannel", "cali", "weave", "kube",
+	"tun", "tap", "wg", "tailscale", "zt", "ppp", "ipsec", "nordlynx",
+}
+
+// Candidate is an interface address considered by SelectPrimary
+type Candidate struct {
+	Interface Interface `json:"interface"`
+	Address   Address   `json:"address"`
+	Score     int       `json:"score"`
+	Reasons   []string  `json:"reasons"`
+	Excluded  bool      `json:"excluded"`
+}
+
+// Explain returns the scoring reasons as a single line
+func (c Candidate) Explain() string {
+	return strings.Join(c.Reasons, ", ")
+}
+
+// SelectPrimary scores every IPv4 address and returns the one most likely to
+// face the PS2, preferring wired physical links with carrier and the default
+// route. The remaining candidates are returned in score order.
+func SelectPrimary() (*Candidate, []Candidate, error) {
+	interfaces, err := ListInterfaces()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defaultIface := ""
+	if route, err := DefaultRoute(); err == nil {
+		defaultIface = route.Iface
+	}
+
+	var candidates []Candidate
+	for _, iface := range interfaces {
+		for _, addr := range iface.IPv4() {
+			candidates = append(candidates, score(iface, addr, defaultIface))
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Excluded != candidates[j].Excluded {
+			return !candidates[i].Excluded
+		}
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	if len(candidates) == 0 || candidates[0].Excluded {
+		return nil, candidates, fmt.Errorf("no suitable IPv4 interface found")
+	}
+	return &candidates[0], candidates, nil
+}
+
+func score(iface Interface, addr Address, defaultIface string) Candidate {
+	c := Candidate{Interface: iface, Address: addr}
+	add := func(points int, reason string) {
+		c.Score += points
+		c.Reasons = append(c.Reasons, fmt.Sprintf("%s (%+d)", reason, points))
+	}
+
+	for _, prefix := range excludedPrefixes {
+		if strings.HasPrefix(iface.Name, prefix) {
+			c.Excluded = true
+			c.Reasons = append(c.Reasons, "container/VPN interface, excluded")
+			return c
+		}
+	}
+	if !iface.Up() {
+		c.Excluded = true
+		c.Reasons = append(c.Reasons, "interface down, excluded")
+		return c
+	}
+
+	switch iface.Kind {
+	case KindWired:
+		add(40, "wired")
+	case KindWireless:
+		add(10, "wireless")
+	case KindBridge:
+		// Container and VM bridges are excluded by name above. A bridge left
+		// here, such as br0 on a hypervisor host, usually carries the LAN
+		// address of the network card enslaved to it, and that card has no
+		// address of its own, so the bridge is the only way to reach the
+		// PS2. It is kept but ranked below physical links
+		add(-30, "bridge")
+	case KindVirtual:
+		add(-40, "virtual")
+	}
+
+	if iface.Carrier {
+		add(20, "carrier")
+	} else {
+		add(-50, "no carrier")
+	}
+
+	if iface.Name == defaultIface {
+		add(25, "default route")
+	}
+
+	ip := addr.IP.To4()
+	switch {
+	case ip.IsLinkLocalUnicast():
+		add(-20, "link-local address")
+	case ip.IsPrivate():
+		add(10, "private address")
+	}
+
+	return c
+}
+
+// PS2Subnet returns the subnet the PS2 is expected on: that of iface when
+// given, otherwise that of the auto-selected primary interface
+func PS2Subnet(iface string) (*Subnet, error) {
+	if iface == "" {
+		best, _, err := SelectPrimary()
+		if err != nil {
+			return nil, err
+		}
+		subnet := NewSubnet(best.Address)
+		subnet.Interface = best.Interface.Name
+		return subnet, nil
+	}
+
+	info, err := GetInterface(iface)
+	if err != nil {
+		return nil, fmt.Errorf("interface %s not found: %v", iface, err)
+	}
+	addrs := info.IPv4()
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no IPv4 address found on interface %s", iface)
+	}
+	return SubnetFor(iface, addrs[0].IP)
+}
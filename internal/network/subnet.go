@@ -0,0 +1,179 @@
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// maxCandidates bounds how many addresses are proposed on large subnets
+const maxCandidates = 256
+
+// Subnet describes the IPv4 network an interface address belongs to
+type Subnet struct {
+	Interface string     `json:"interface"`
+	IP        net.IP     `json:"ip"`
+	Network   net.IP     `json:"network"`
+	Mask      net.IPMask `json:"-"`
+	PrefixLen int        `json:"prefix_len"`
+	Broadcast net.IP     `json:"broadcast"`
+	Gateway   net.IP     `json:"gateway,omitempty"`
+}
+
+// SubnetFor computes the subnet of ip on the given interface, including
+// the default gateway from the routing table when one exists
+func SubnetFor(ifaceName string, ip net.IP) (*Subnet, error) {
+	iface, err := GetInterface(ifaceName)
+	if err != nil {
+		return nil, fmt.Errorf("interface %s not found: %v", ifaceName, err)
+	}
+
+	for _, addr := range iface.IPv4() {
+		if !addr.IP.Equal(ip) {
+			continue
+		}
+		subnet := NewSubnet(addr)
+		subnet.Interface = ifaceName
+		if gw, err := GatewayFor(ifaceName); err == nil && subnet.Contains(gw) {
+			subnet.Gateway = gw
+		}
+		return subnet, nil
+	}
+
+	return nil, fmt.Errorf("address %s not found on interface %s", ip, ifaceName)
+}
+
+// NewSubnet computes network and broadcast addresses for an IPv4 address
+func NewSubnet(addr Address) *Subnet {
+	ip := addr.IP.To4()
+	mask := net.CIDRMask(addr.PrefixLen, 32)
+	network := ip.Mask(mask)
+
+	broadcast := make(net.IP, 4)
+	for i := range broadcast {
+		broadcast[i] = network[i] | ^mask[i]
+	}
+
+	return &Subnet{
+		IP:        ip,
+		Network:   network,
+		Mask:      mask,
+		PrefixLen: addr.PrefixLen,
+		Broadcast: broadcast,
+	}
+}
+
+// String returns the subnet in CIDR notation
+func (s *Subnet) String() string {
+	return fmt.Sprintf("%s/%d", s.Network, s.PrefixLen)
+}
+
+// MaskString returns the netmask in dotted-decimal form
+func (s *Subnet) MaskString() string {
+	return net.IP(s.Mask).String()
+}
+
+// Contains reports whether ip is inside the subnet
+func (s *Subnet) Contains(ip net.IP) bool {
+	ip = ip.To4()
+	return ip != nil && ip.Mask(s.Mask).Equal(s.Network)
+}
+
+// Candidates returns host addresses that could be given to the PS2, in order
+// of preference. Round addresses (.10, .20, ...) come first, then the rest of
+// the range; the server's own address and the gateway are never returned.
+func (s *Subnet) Candidates() []net.IP {
+	first := ipToUint(s.Network) + 1
+	last := ipToUint(s.Broadcast) - 1
+	if s.PrefixLen >= 31 {
+		// Point-to-point links have no network/broadcast addresses
+		first, last = ipToUint(s.Network), ipToUint(s.Broadcast)
+	}
+
+	skip := map[uint32]bool{ipToUint(s.IP): true}
+	if s.Gateway != nil {
+		skip[ipToUint(s.Gateway)] = true
+	}
+
+	var result []net.IP
+	seen := make(map[uint32]bool)
+	add := func(n uint32) bool {
+		if n < first || n > last || skip[n] || seen[n] {
+			return len(result) < maxCandidates
+		}
+		seen[n] = true
+		result = append(result, uintToIP(n))
+		return len(result) < maxCandidates
+	}
+
+	base := ipToUint(s.Network)
+	for n := base + 10; n <= last && n > base; n += 10 {
+		if !add(n) {
+			return result
+		}
+	}
+	for n := first; n <= last && n >= first; n++ {
+		if !add(n) {
+			return result
+		}
+	}
+	return result
+}
+
+// SuggestPS2IP proposes a static address for the PS2 inside the subnet
+func (s *Subnet) SuggestPS2IP() (net.IP, error) {
+	candidates := s.Candidates()
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no free addresses in %s", s)
+	}
+	return candidates[0], nil
+}
+
+// Pool returns a contiguous address range of up to size hosts starting at
+// the suggested PS2 address, for handing out over DHCP. The range ends
+// before the server's own address or the gateway if either falls inside it
+func (s *Subnet) Pool(size int) (net.IP, net.IP, error) {
+	start, err := s.SuggestPS2IP()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	last := ipToUint(s.Broadcast) - 1
+	if s.PrefixLen >= 31 {
+		last = ipToUint(s.Broadcast)
+	}
+	end := ipToUint(start) + uint32(size) - 1
+	if end > last || end < ipToUint(start) {
+		end = last
+	}
+	for _, ip := range []net.IP{s.IP, s.Gateway} {
+		if ip == nil {
+			continue
+		}
+		// start is never the server or gateway, so n-1 stays in the range
+		if n := ipToUint(ip); n > ipToUint(start) && n <= end {
+			end = n - 1
+		}
+	}
+	return start, uintToIP(end), nil
+}
+
+// FormatOPL renders an IPv4 address the way OPL's network settings screen
+// shows it, with every octet padded to three digits (e.g. 192.168.001.010)
+func FormatOPL(ip net.IP) string {
+	v4 := ip.To4()
+	if v4 == nil {
+		return ip.String()
+	}
+	return fmt.Sprintf("%03d.%03d.%03d.%03d", v4[0], v4[1], v4[2], v4[3])
+}
+
+func ipToUint(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
+
+func uintToIP(n uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, n)
+	return ip
+}
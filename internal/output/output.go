@@ -0,0 +1,96 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// Supported values for the --output flag
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Options selects how a command renders its result
+type Options struct {
+	Format   string
+	Template string
+}
+
+// Structured reports whether the result should be rendered as data
+// instead of the command's human-readable text
+func (o Options) Structured() bool {
+	return o.Template != "" || (o.Format != "" && o.Format != FormatText)
+}
+
+// Validate checks that the requested format is supported
+func (o Options) Validate() error {
+	switch o.Format {
+	case "", FormatText, FormatJSON, FormatYAML:
+	default:
+		return fmt.Errorf("unsupported output format %q (use text, json or yaml)", o.Format)
+	}
+	if o.Template != "" && o.Format != "" && o.Format != FormatText {
+		return fmt.Errorf("--format cannot be combined with --output %s", o.Format)
+	}
+	return nil
+}
+
+// Render writes v to w as JSON, YAML or through a Go template
+func Render(w io.Writer, opts Options, v any) error {
+	if opts.Template != "" {
+		return renderTemplate(w, opts.Template, v)
+	}
+
+	switch opts.Format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode JSON: %v", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case FormatYAML:
+		data, err := MarshalYAML(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode YAML: %v", err)
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	return fmt.Errorf("unsupported output format %q", opts.Format)
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+func renderTemplate(w io.Writer, text string, v any) error {
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, v); err != nil {
+		return fmt.Errorf("failed to execute template: %v", err)
+	}
+
+	out := sb.String()
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
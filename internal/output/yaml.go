@@ -0,0 +1,250 @@
+package output
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MarshalYAML encodes v as a YAML document. Field names and omitempty
+// follow the json struct tags so both formats share one schema.
+func MarshalYAML(v any) ([]byte, error) {
+	scalar, lines, isScalar, err := encodeNode(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	if isScalar {
+		return []byte(scalar + "\n"), nil
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// encodeNode returns either a scalar rendering or the lines of a block
+func encodeNode(v reflect.Value) (string, []string, bool, error) {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return "null", nil, true, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return "null", nil, true, nil
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", nil, false, err
+		}
+		return quoteString(string(text)), nil, true, nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return quoteString(v.String()), nil, true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil, true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil, true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil, true, nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil, true, nil
+	case reflect.Slice, reflect.Array:
+		return encodeSeq(v)
+	case reflect.Map:
+		return encodeMap(v)
+	case reflect.Struct:
+		return encodeStruct(v)
+	}
+
+	return "", nil, false, fmt.Errorf("cannot encode %s as YAML", v.Type())
+}
+
+func encodeSeq(v reflect.Value) (string, []string, bool, error) {
+	if v.Kind() == reflect.Slice && v.IsNil() || v.Len() == 0 {
+		return "[]", nil, true, nil
+	}
+
+	var lines []string
+	for i := 0; i < v.Len(); i++ {
+		scalar, block, isScalar, err := encodeNode(v.Index(i))
+		if err != nil {
+			return "", nil, false, err
+		}
+		if isScalar {
+			lines = append(lines, "- "+scalar)
+			continue
+		}
+		for j, line := range block {
+			if j == 0 {
+				lines = append(lines, "- "+line)
+			} else {
+				lines = append(lines, "  "+line)
+			}
+		}
+	}
+	return "", lines, false, nil
+}
+
+func encodeMap(v reflect.Value) (string, []string, bool, error) {
+	if v.Len() == 0 {
+		return "{}", nil, true, nil
+	}
+
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+
+	var lines []string
+	for _, k := range keys {
+		entry, err := encodeEntry(fmt.Sprint(k.Interface()), v.MapIndex(k))
+		if err != nil {
+			return "", nil, false, err
+		}
+		lines = append(lines, entry...)
+	}
+	return "", lines, false, nil
+}
+
+func encodeStruct(v reflect.Value) (string, []string, bool, error) {
+	t := v.Type()
+
+	var lines []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := parseTag(field)
+		if skip {
+			continue
+		}
+
+		fv := v.Field(i)
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+		if omitEmpty && (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Map) && fv.Len() == 0 {
+			continue
+		}
+
+		entry, err := encodeEntry(name, fv)
+		if err != nil {
+			return "", nil, false, err
+		}
+		lines = append(lines, entry...)
+	}
+
+	if len(lines) == 0 {
+		return "{}", nil, true, nil
+	}
+	return "", lines, false, nil
+}
+
+func encodeEntry(key string, v reflect.Value) ([]string, error) {
+	scalar, block, isScalar, err := encodeNode(v)
+	if err != nil {
+		return nil, err
+	}
+	if isScalar {
+		return []string{quoteKey(key) + ": " + scalar}, nil
+	}
+
+	lines := []string{quoteKey(key) + ":"}
+	for _, line := range block {
+		lines = append(lines, "  "+line)
+	}
+	return lines, nil
+}
+
+func parseTag(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(","+opts+",", ",omitempty,"), false
+}
+
+func quoteKey(key string) string {
+	if needsQuotes(key) {
+		return jsonQuote(key)
+	}
+	return key
+}
+
+func quoteString(s string) string {
+	if needsQuotes(s) {
+		return jsonQuote(s)
+	}
+	return s
+}
+
+var (
+	yamlSexagesimal = regexp.MustCompile(`^[-+]?[0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?$`)
+	yamlTimestamp   = regexp.MustCompile(`^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}([Tt ]|$)`)
+)
+
+// needsQuotes reports whether a plain scalar would be misread by a YAML parser
+func needsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	// Hex, octal and binary integers, and underscore digit groups
+	if _, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case ".inf", "+.inf", ".nan":
+		return true
+	}
+	// YAML 1.1 reads 1:30 as the base 60 number 90 and parses dates
+	if yamlSexagesimal.MatchString(s) || yamlTimestamp.MatchString(s) {
+		return true
+	}
+
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonQuote produces a double-quoted scalar; JSON strings are valid YAML
+func jsonQuote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -149,20 +149,7 @@ func AddPS2Share(gamesPath string, useGuest bool) error {
 
 // EnableSMBv1 enables SMB v1 protocol (required for PS2)
 func EnableSMBv1() error {
-	if !IsRoot() {
-		return fmt.Errorf("root privileges required")
-	}
-
-	// Simple append to global section
-	globalConfig := "\n   min protocol = NT1\n"
-	
-	f, err := os.OpenFile(SmbConfPath, os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open smb.conf: %v", err)
-	}
-	defer f.Close()
-
-	if _, err = f.WriteString(globalConfig); err != nil {
+	if err := SetParams("global", []Param{{"server min protocol", "NT1"}}); err != nil {
 		return fmt.Errorf("failed to enable SMB v1: %v", err)
 	}
 
@@ -214,6 +201,21 @@ func RestartSamba() error {
 	return nil
 }
 
+// StartSamba starts the Samba service
+func StartSamba() error {
+	if !IsRoot() {
+		return fmt.Errorf("root privileges required")
+	}
+
+	serviceName := GetSambaServiceName()
+	cmd := exec.Command("systemctl", "start", serviceName)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to start Samba: %v", err)
+	}
+
+	return nil
+}
+
 // EnableSamba enables Samba to start on boot
 func EnableSamba() error {
 	if !IsRoot() {
@@ -228,3 +230,34 @@ func EnableSamba() error {
 
 	return nil
 }
+
+// EnableNmbd enables the NetBIOS name service and starts it now
+func EnableNmbd() error {
+	if !IsRoot() {
+		return fmt.Errorf("root privileges required")
+	}
+
+	serviceName := GetNmbdServiceName()
+	cmd := exec.Command("systemctl", "enable", "--now", serviceName)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to enable %s: %v", serviceName, err)
+	}
+
+	return nil
+}
+
+// RestartNmbd restarts the NetBIOS name service if it is running, so a new
+// name or workgroup is announced
+func RestartNmbd() error {
+	if !IsNmbdRunning() {
+		return nil
+	}
+
+	serviceName := GetNmbdServiceName()
+	cmd := exec.Command("systemctl", "restart", serviceName)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to restart %s: %v", serviceName, err)
+	}
+
+	return nil
+}
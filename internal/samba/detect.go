@@ -77,6 +77,14 @@ func IsSambaRunning() bool {
 	return err == nil
 }
 
+// IsSambaEnabled checks if Samba service starts on boot
+func IsSambaEnabled() bool {
+	serviceName := GetSambaServiceName()
+	cmd := exec.Command("systemctl", "is-enabled", serviceName)
+	err := cmd.Run()
+	return err == nil
+}
+
 // GetSambaServiceName returns the correct service name for the distro
 func GetSambaServiceName() string {
 	distro, err := DetectDistro()
@@ -84,10 +92,36 @@ func GetSambaServiceName() string {
 		return "smbd" // default
 	}
 
-	// Arch uses 'smb', most others use 'smbd'
-	if distro.PackageManager == "pacman" {
+	// Arch and Fedora use 'smb', Debian-based distros use 'smbd'
+	if distro.PackageManager == "pacman" || distro.PackageManager == "dnf" {
 		return "smb"
 	}
 
 	return "smbd"
 }
+
+// IsNmbdInstalled checks if the NetBIOS name server is installed
+func IsNmbdInstalled() bool {
+	_, err := exec.LookPath("nmbd")
+	return err == nil
+}
+
+// IsNmbdRunning checks if the NetBIOS name service is running
+func IsNmbdRunning() bool {
+	cmd := exec.Command("systemctl", "is-active", GetNmbdServiceName())
+	return cmd.Run() == nil
+}
+
+// IsNmbdEnabled checks if the NetBIOS name service starts on boot
+func IsNmbdEnabled() bool {
+	cmd := exec.Command("systemctl", "is-enabled", GetNmbdServiceName())
+	return cmd.Run() == nil
+}
+
+// GetNmbdServiceName returns the NetBIOS name service unit for the distro
+func GetNmbdServiceName() string {
+	if GetSambaServiceName() == "smb" {
+		return "nmb"
+	}
+	return "nmbd"
+}
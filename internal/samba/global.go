@@ -0,0 +1,284 @@
+package samba
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Param is a single smb.conf key/value pair
+type Param struct {
+	Key   string
+	Value string
+}
+
+// RequiredGlobals returns the [global] settings OPL needs to talk to Samba.
+// The PS2 only speaks SMB1 (NT LM 0.12) and authenticates with NTLMv1.
+func RequiredGlobals(useGuest bool) []Param {
+	params := []Param{
+		{"server min protocol", "NT1"},
+	}
+	if useGuest {
+		params = append(params, Param{"map to guest", "Bad User"})
+	} else {
+		params = append(params, Param{"ntlm auth", "ntlmv1-permitted"})
+	}
+	return params
+}
+
+// MissingGlobals returns the required settings that conf does not satisfy
+func MissingGlobals(conf *SmbConf, useGuest bool) []Param {
+	global := conf.Section("global")
+
+	var missing []Param
+	for _, p := range RequiredGlobals(useGuest) {
+		if global == nil {
+			missing = append(missing, p)
+			continue
+		}
+		v, ok := global.Get(p.Key)
+		if !ok || !strings.EqualFold(strings.Join(strings.Fields(v), " "), p.Value) {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+// ApplyGlobalSettings writes the settings required by OPL into [global]
+func ApplyGlobalSettings(useGuest bool) error {
+	return SetParams("global", RequiredGlobals(useGuest))
+}
+
+// SetParams sets parameters inside a section of smb.conf, replacing any
+// existing values (including synonyms) and creating the section if needed
+func SetParams(section string, params []Param) error {
+	if !IsRoot() {
+		return fmt.Errorf("root privileges required")
+	}
+
+	data, err := os.ReadFile(SmbConfPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read smb.conf: %v", err)
+	}
+
+	content := setParams(string(data), section, params)
+	if err := os.WriteFile(SmbConfPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write smb.conf: %v", err)
+	}
+
+	return nil
+}
+
+// UnsetParams removes parameters (and their synonyms) from a section of smb.conf
+func UnsetParams(section string, keys ...string) error {
+	if !IsRoot() {
+		return fmt.Errorf("root privileges required")
+	}
+
+	data, err := os.ReadFile(SmbConfPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read smb.conf: %v", err)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	start, end := findSection(lines, section)
+	if start == -1 {
+		return nil
+	}
+
+	var out []string
+	out = append(out, lines[:start+1]...)
+	for _, line := range lines[start+1 : end] {
+		if key, ok := paramKey(line); ok && matchesAny(key, keys) {
+			continue
+		}
+		out = append(out, line)
+	}
+	out = append(out, lines[end:]...)
+
+	if err := os.WriteFile(SmbConfPath, []byte(strings.Join(out, "\n")), 0644); err != nil {
+		return fmt.Errorf("failed to write smb.conf: %v", err)
+	}
+
+	return nil
+}
+
+func setParams(content, section string, params []Param) string {
+	lines := strings.Split(content, "\n")
+	start, end := findSection(lines, section)
+
+	if start == -1 {
+		block := []string{"[" + section + "]"}
+		for _, p := range params {
+			block = append(block, formatParam(p))
+		}
+		if strings.EqualFold(section, "global") {
+			return strings.Join(block, "\n") + "\n\n" + content
+		}
+		return strings.TrimRight(content, "\n") + "\n\n" + strings.Join(block, "\n") + "\n"
+	}
+
+	var out []string
+	out = append(out, lines[:start+1]...)
+
+	written := make(map[string]bool)
+	for _, line := range lines[start+1 : end] {
+		key, ok := paramKey(line)
+		if !ok {
+			out = append(out, line)
+			continue
+		}
+		replaced := false
+		for _, p := range params {
+			if matchesAny(key, []string{p.Key}) {
+				if !written[p.Key] {
+					out = append(out, formatParam(p))
+					written[p.Key] = true
+				}
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			out = append(out, line)
+		}
+	}
+
+	// Insert new parameters after the last non-blank line of the section
+	insertAt := len(out)
+	for insertAt > start+1 && strings.TrimSpace(out[insertAt-1]) == "" {
+		insertAt--
+	}
+	var added []string
+	for _, p := range params {
+		if !written[p.Key] {
+			added = append(added, formatParam(p))
+		}
+	}
+
+	result := append([]string{}, out[:insertAt]...)
+	result = append(result, added...)
+	result = append(result, out[insertAt:]...)
+	result = append(result, lines[end:]...)
+	return strings.Join(result, "\n")
+}
+
+// findSection returns the header line index of a section and the index of
+// the next section header (or len(lines)), or -1 if it does not exist
+func findSection(lines []string, name string) (int, int) {
+	start := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "[") || !strings.HasSuffix(trimmed, "]") {
+			continue
+		}
+		if start != -1 {
+			return start, i
+		}
+		if strings.EqualFold(strings.TrimSpace(trimmed[1:len(trimmed)-1]), name) {
+			start = i
+		}
+	}
+	return start, len(lines)
+}
+
+// paramKey returns the normalized key of a parameter line
+func paramKey(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';' {
+		return "", false
+	}
+	key, _, ok := strings.Cut(trimmed, "=")
+	if !ok {
+		return "", false
+	}
+	return normalizeKey(key), true
+}
+
+func matchesAny(key string, keys []string) bool {
+	for _, k := range keys {
+		k = normalizeKey(k)
+		if key == k {
+			return true
+		}
+		for _, alias := range synonyms[k] {
+			if key == alias {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func formatParam(p Param) string {
+	return fmt.Sprintf("   %s = %s", p.Key, p.Value)
+}
+
+// BindToInterface restricts smbd to loopback and the given interface
+func BindToInterface(iface string) error {
+	return SetParams("global", []Param{
+		{"interfaces", "lo " + iface},
+		{"bind interfaces only", "yes"},
+	})
+}
+
+// UnbindInterface lets smbd listen on every interface again, undoing
+// BindToInterface(iface). A binding that differs from the one ps2smb wrote
+// was set by the admin and is left alone
+func UnbindInterface(iface string) error {
+	conf, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	global := conf.Section("global")
+	if global == nil {
+		return nil
+	}
+	v, ok := global.Get("interfaces")
+	if !ok || strings.Join(SplitList(v), " ") != "lo "+iface {
+		return nil
+	}
+	return UnsetParams("global", "interfaces", "bind interfaces only")
+}
+
+// SetHostsAllow limits which clients may connect to the PS2 share.
+// An empty value removes the restriction.
+func SetHostsAllow(hosts string) error {
+	if hosts == "" {
+		return UnsetParams(ShareName, "hosts allow")
+	}
+	return SetParams(ShareName, []Param{{"hosts allow", hosts}})
+}
+
+// SetIdentity sets 'netbios name' and 'workgroup' in [global]. Empty
+// values are left untouched
+func SetIdentity(netbiosName, workgroup string) error {
+	var params []Param
+	if netbiosName != "" {
+		params = append(params, Param{"netbios name", netbiosName})
+	}
+	if workgroup != "" {
+		params = append(params, Param{"workgroup", workgroup})
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return SetParams("global", params)
+}
+
+// BoundInterfaces returns the interfaces smbd is restricted to, or nil when
+// it listens on all of them
+func BoundInterfaces(conf *SmbConf) []string {
+	global := conf.Section("global")
+	if global == nil || !global.GetBool("bind interfaces only", false) {
+		return nil
+	}
+	v, ok := global.Get("interfaces")
+	if !ok {
+		return nil
+	}
+	return SplitList(v)
+}
@@ -0,0 +1,145 @@
+package samba
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Section is a single [name] block of smb.conf
+type Section struct {
+	Name   string
+	Params map[string]string
+}
+
+// SmbConf is a parsed view of smb.conf
+type SmbConf struct {
+	Sections []*Section
+}
+
+// ParseConfig reads and parses an smb.conf file
+func ParseConfig(path string) (*SmbConf, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	conf := &SmbConf{}
+	var current *Section
+	var pending string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Samba joins lines ending with a backslash
+		if strings.HasSuffix(line, "\\") {
+			pending += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		line = strings.TrimSpace(pending + line)
+		pending = ""
+
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = &Section{
+				Name:   strings.TrimSpace(line[1 : len(line)-1]),
+				Params: make(map[string]string),
+			}
+			conf.Sections = append(conf.Sections, current)
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || current == nil {
+			continue
+		}
+		current.Params[normalizeKey(key)] = strings.TrimSpace(value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	return conf, nil
+}
+
+// LoadConfig parses the system smb.conf
+func LoadConfig() (*SmbConf, error) {
+	return ParseConfig(SmbConfPath)
+}
+
+// Section returns the section with the given name, or nil if it does not exist
+func (c *SmbConf) Section(name string) *Section {
+	for _, s := range c.Sections {
+		if strings.EqualFold(s.Name, name) {
+			return s
+		}
+	}
+	return nil
+}
+
+// Get returns the value of a parameter, honoring common Samba synonyms
+func (s *Section) Get(key string) (string, bool) {
+	key = normalizeKey(key)
+	if v, ok := s.Params[key]; ok {
+		return v, true
+	}
+	for _, alias := range synonyms[key] {
+		if v, ok := s.Params[alias]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// GetBool returns a boolean parameter, or def if it is unset or invalid
+func (s *Section) GetBool(key string, def bool) bool {
+	v, ok := s.Get(key)
+	if !ok {
+		return def
+	}
+	b, ok := ParseBool(v)
+	if !ok {
+		return def
+	}
+	return b
+}
+
+// ParseBool parses a Samba boolean value (yes/no, true/false, 1/0, on/off)
+func ParseBool(v string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "yes", "true", "1", "on":
+		return true, true
+	case "no", "false", "0", "off":
+		return false, true
+	}
+	return false, false
+}
+
+// SplitList splits a Samba list parameter on commas and whitespace
+func SplitList(v string) []string {
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+var synonyms = map[string][]string{
+	"guest ok":            {"public"},
+	"public":              {"guest ok"},
+	"server min protocol": {"min protocol"},
+	"min protocol":        {"server min protocol"},
+	"hosts allow":         {"allow hosts"},
+	"allow hosts":         {"hosts allow"},
+}
+
+// normalizeKey lowercases a parameter name and collapses inner whitespace,
+// matching how Samba itself compares parameter names
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.Join(strings.Fields(key), " "))
+}
@@ -0,0 +1,137 @@
+package smb1
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NT_CREATE_ANDX parameters for opening an existing file read-only
+const (
+	accessGenericRead   = 0x00120089
+	shareReadWrite      = 0x00000003
+	dispositionOpen     = 0x00000001
+	optionNonDirectory  = 0x00000040
+	impersonationImpers = 0x00000002
+)
+
+// File is an open file on the connected share
+type File struct {
+	c    *Conn
+	fid  uint16
+	name string
+	size int64
+}
+
+// Open opens an existing file on the share for reading. Paths use forward
+// or back slashes relative to the share root
+func (c *Conn) Open(name string) (*File, error) {
+	path := `\` + strings.Trim(strings.ReplaceAll(name, "/", `\`), `\`)
+	encoded := encodeUTF16(path)
+
+	words := []byte{andxNone, 0, 0, 0, 0}
+	words = binary.LittleEndian.AppendUint16(words, uint16(len(encoded)))
+	words = binary.LittleEndian.AppendUint32(words, 0) // flags
+	words = binary.LittleEndian.AppendUint32(words, 0) // root directory FID
+	words = binary.LittleEndian.AppendUint32(words, accessGenericRead)
+	words = binary.LittleEndian.AppendUint64(words, 0) // allocation size
+	words = binary.LittleEndian.AppendUint32(words, 0) // attributes
+	words = binary.LittleEndian.AppendUint32(words, shareReadWrite)
+	words = binary.LittleEndian.AppendUint32(words, dispositionOpen)
+	words = binary.LittleEndian.AppendUint32(words, optionNonDirectory)
+	words = binary.LittleEndian.AppendUint32(words, impersonationImpers)
+	words = append(words, 0) // security flags
+
+	var data []byte
+	if (headerSize+3+len(words))%2 != 0 {
+		data = append(data, 0)
+	}
+	data = append(data, encoded...)
+	data = append(data, 0, 0)
+
+	r, err := c.call(cmdNTCreateAndX, words, data)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %v", name, err)
+	}
+	if len(r.words) < 68 {
+		return nil, fmt.Errorf("open %s: malformed response", name)
+	}
+
+	return &File{
+		c:    c,
+		fid:  binary.LittleEndian.Uint16(r.words[5:7]),
+		name: name,
+		size: int64(binary.LittleEndian.Uint64(r.words[55:63])),
+	}, nil
+}
+
+// Size returns the file size reported when the file was opened
+func (f *File) Size() int64 {
+	return f.size
+}
+
+// maxRead is the largest READ_ANDX payload that fits the server's buffer
+func (f *File) maxRead() int {
+	n := 0xffff
+	if mbs := int(f.c.negotiated.MaxBufferSize); mbs > 0 && mbs-64 < n {
+		n = mbs - 64
+	}
+	return n
+}
+
+// ReadAt implements io.ReaderAt with one READ_ANDX per buffer-sized chunk
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	total := 0
+	for total < len(p) {
+		if off >= f.size {
+			return total, io.EOF
+		}
+		chunk := min(len(p)-total, f.maxRead())
+
+		n, err := f.readChunk(p[total:total+chunk], off)
+		total += n
+		off += int64(n)
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			return total, io.EOF
+		}
+	}
+	return total, nil
+}
+
+func (f *File) readChunk(p []byte, off int64) (int, error) {
+	words := []byte{andxNone, 0, 0, 0}
+	words = binary.LittleEndian.AppendUint16(words, f.fid)
+	words = binary.LittleEndian.AppendUint32(words, uint32(off))
+	words = binary.LittleEndian.AppendUint16(words, uint16(len(p)))
+	words = binary.LittleEndian.AppendUint16(words, uint16(len(p)))
+	words = binary.LittleEndian.AppendUint32(words, 0) // timeout / max count high
+	words = binary.LittleEndian.AppendUint16(words, 0) // remaining
+	words = binary.LittleEndian.AppendUint32(words, uint32(off>>32))
+
+	r, err := f.c.call(cmdReadAndX, words, nil)
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %v", f.name, err)
+	}
+	if len(r.words) < 16 {
+		return 0, fmt.Errorf("read %s: malformed response", f.name)
+	}
+
+	length := int(binary.LittleEndian.Uint16(r.words[10:12])) | int(binary.LittleEndian.Uint16(r.words[14:16]))<<16
+	offset := int(binary.LittleEndian.Uint16(r.words[12:14]))
+	if length > len(p) || offset+length > len(r.raw) {
+		return 0, fmt.Errorf("read %s: response out of bounds", f.name)
+	}
+	return copy(p, r.raw[offset:offset+length]), nil
+}
+
+// Close releases the file handle on the server
+func (f *File) Close() error {
+	words := binary.LittleEndian.AppendUint16(nil, f.fid)
+	words = binary.LittleEndian.AppendUint32(words, 0xffffffff) // leave mtime alone
+	_, err := f.c.call(cmdClose, words, nil)
+	return err
+}
@@ -0,0 +1,211 @@
+package smb1
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TRANS2 subcommands and find parameters
+const (
+	trans2FindFirst2 = 0x0001
+	trans2FindNext2  = 0x0002
+
+	findBothDirectoryInfo = 0x0104
+	findCloseAtEOS        = 0x0002
+	findContinue          = 0x0008
+	findAttributes        = 0x0016 // hidden, system and directories
+	findSearchCount       = 512
+
+	attrDirectory = 0x10
+)
+
+// FileInfo is a directory entry returned by List
+type FileInfo struct {
+	Name    string
+	Size    int64
+	IsDir   bool
+	ModTime time.Time
+}
+
+// List returns the entries of a directory on the connected share, using
+// TRANS2 FIND_FIRST2/FIND_NEXT2 the same way OPL scans DVD/ and CD/
+func (c *Conn) List(dir string) ([]FileInfo, error) {
+	pattern := `\` + strings.Trim(strings.ReplaceAll(dir, "/", `\`), `\`) + `\*`
+	if dir == "" || dir == "/" {
+		pattern = `\*`
+	}
+
+	params := binary.LittleEndian.AppendUint16(nil, findAttributes)
+	params = binary.LittleEndian.AppendUint16(params, findSearchCount)
+	params = binary.LittleEndian.AppendUint16(params, findCloseAtEOS)
+	params = binary.LittleEndian.AppendUint16(params, findBothDirectoryInfo)
+	params = binary.LittleEndian.AppendUint32(params, 0)
+	params = append(params, encodeUTF16(pattern+"\x00")...)
+
+	rparams, rdata, err := c.trans2(trans2FindFirst2, params, nil)
+	if err != nil {
+		var status StatusError
+		if errors.As(err, &status) && (status == 0xc000000f || status == 0x80000006) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(rparams) < 10 {
+		return nil, fmt.Errorf("malformed FIND_FIRST2 response")
+	}
+	sid := binary.LittleEndian.Uint16(rparams[0:2])
+	eos := binary.LittleEndian.Uint16(rparams[4:6]) != 0
+
+	var files []FileInfo
+	last := parseDirectoryInfo(rdata, &files)
+
+	for !eos && last != "" {
+		params = binary.LittleEndian.AppendUint16(nil, sid)
+		params = binary.LittleEndian.AppendUint16(params, findSearchCount)
+		params = binary.LittleEndian.AppendUint16(params, findBothDirectoryInfo)
+		params = binary.LittleEndian.AppendUint32(params, 0)
+		params = binary.LittleEndian.AppendUint16(params, findCloseAtEOS|findContinue)
+		params = append(params, encodeUTF16(last+"\x00")...)
+
+		rparams, rdata, err = c.trans2(trans2FindNext2, params, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(rparams) < 8 {
+			return nil, fmt.Errorf("malformed FIND_NEXT2 response")
+		}
+		if binary.LittleEndian.Uint16(rparams[0:2]) == 0 {
+			break
+		}
+		eos = binary.LittleEndian.Uint16(rparams[2:4]) != 0
+		last = parseDirectoryInfo(rdata, &files)
+	}
+
+	return files, nil
+}
+
+// parseDirectoryInfo appends SMB_FIND_FILE_BOTH_DIRECTORY_INFO entries to
+// files and returns the name of the last entry seen
+func parseDirectoryInfo(data []byte, files *[]FileInfo) string {
+	last := ""
+	for off := 0; off+94 <= len(data); {
+		entry := data[off:]
+		next := int(binary.LittleEndian.Uint32(entry[0:4]))
+		nameLen := int(binary.LittleEndian.Uint32(entry[60:64]))
+		if 94+nameLen > len(entry) {
+			break
+		}
+
+		name := decodeUTF16(entry[94 : 94+nameLen])
+		last = name
+		if name != "." && name != ".." {
+			*files = append(*files, FileInfo{
+				Name:    name,
+				Size:    int64(binary.LittleEndian.Uint64(entry[40:48])),
+				IsDir:   binary.LittleEndian.Uint32(entry[56:60])&attrDirectory != 0,
+				ModTime: filetime(binary.LittleEndian.Uint64(entry[24:32])),
+			})
+		}
+
+		if next == 0 {
+			break
+		}
+		off += next
+	}
+	return last
+}
+
+// filetime converts a Windows FILETIME (100ns ticks since 1601) to time.Time
+func filetime(ft uint64) time.Time {
+	if ft == 0 {
+		return time.Time{}
+	}
+	const epochDelta = 116444736000000000
+	return time.Unix(0, (int64(ft)-epochDelta)*100)
+}
+
+// trans2 issues an SMB_COM_TRANSACTION2 request and reassembles the
+// parameter and data blocks, which may span several responses
+func (c *Conn) trans2(setup uint16, params, data []byte) ([]byte, []byte, error) {
+	const wordCount = 15
+	maxData := 16384
+	if mbs := int(c.negotiated.MaxBufferSize); mbs > 0 && mbs-256 < maxData {
+		maxData = mbs - 256
+	}
+
+	// name byte plus padding so the parameter block is 4-byte aligned
+	base := headerSize + 1 + wordCount*2 + 2
+	paramOff := align4(base + 1)
+	dataOff := align4(paramOff + len(params))
+
+	words := binary.LittleEndian.AppendUint16(nil, uint16(len(params)))
+	words = binary.LittleEndian.AppendUint16(words, uint16(len(data)))
+	words = binary.LittleEndian.AppendUint16(words, 64) // max parameter count
+	words = binary.LittleEndian.AppendUint16(words, uint16(maxData))
+	words = append(words, 0, 0)                        // max setup count, reserved
+	words = binary.LittleEndian.AppendUint16(words, 0) // flags
+	words = binary.LittleEndian.AppendUint32(words, 0) // timeout
+	words = binary.LittleEndian.AppendUint16(words, 0) // reserved
+	words = binary.LittleEndian.AppendUint16(words, uint16(len(params)))
+	words = binary.LittleEndian.AppendUint16(words, uint16(paramOff))
+	words = binary.LittleEndian.AppendUint16(words, uint16(len(data)))
+	words = binary.LittleEndian.AppendUint16(words, uint16(dataOff))
+	words = append(words, 1, 0) // setup count, reserved
+	words = binary.LittleEndian.AppendUint16(words, setup)
+
+	body := make([]byte, dataOff-base+len(data))
+	copy(body[paramOff-base:], params)
+	copy(body[dataOff-base:], data)
+
+	r, err := c.call(cmdTransaction2, words, body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rparams, rdata []byte
+	gotParams, gotData := 0, 0
+	for {
+		if len(r.words) < 20 {
+			return nil, nil, fmt.Errorf("malformed TRANS2 response")
+		}
+		w := r.words
+		totalParams := int(binary.LittleEndian.Uint16(w[0:2]))
+		totalData := int(binary.LittleEndian.Uint16(w[2:4]))
+		if rparams == nil {
+			rparams = make([]byte, totalParams)
+			rdata = make([]byte, totalData)
+		}
+
+		pCount, pOff, pDisp := int(binary.LittleEndian.Uint16(w[6:8])), int(binary.LittleEndian.Uint16(w[8:10])), int(binary.LittleEndian.Uint16(w[10:12]))
+		dCount, dOff, dDisp := int(binary.LittleEndian.Uint16(w[12:14])), int(binary.LittleEndian.Uint16(w[14:16])), int(binary.LittleEndian.Uint16(w[16:18]))
+		if pOff+pCount > len(r.raw) || dOff+dCount > len(r.raw) || pDisp+pCount > len(rparams) || dDisp+dCount > len(rdata) {
+			return nil, nil, fmt.Errorf("TRANS2 response out of bounds")
+		}
+		copy(rparams[pDisp:], r.raw[pOff:pOff+pCount])
+		copy(rdata[dDisp:], r.raw[dOff:dOff+dCount])
+		gotParams += pCount
+		gotData += dCount
+
+		if gotParams >= totalParams && gotData >= totalData {
+			return rparams[:totalParams], rdata[:totalData], nil
+		}
+
+		raw, err := c.t.readMessage()
+		if err != nil {
+			return nil, nil, err
+		}
+		if r, err = parseResponse(raw); err != nil {
+			return nil, nil, err
+		}
+		if r.status != 0 {
+			return nil, nil, StatusError(r.status)
+		}
+	}
+}
+
+func align4(n int) int {
+	return (n + 3) &^ 3
+}
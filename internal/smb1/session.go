@@ -0,0 +1,112 @@
+package smb1
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Client capabilities announced in session setup
+const (
+	capUnicode    = 0x0004
+	capLargeFiles = 0x0008
+	capNTSMBs     = 0x0010
+	capNTStatus   = 0x0040
+)
+
+// GuestUser is the account OPL logs in with when no user is configured
+const GuestUser = "GUEST"
+
+// SessionSetup logs in with an NTLMv1 response, the only scheme OPL
+// supports. An empty password sends empty responses, which Samba maps to
+// the guest account under "map to guest = Bad User". It reports whether the
+// server logged the session in as guest
+func (c *Conn) SessionSetup(user, password string) (bool, error) {
+	if c.negotiated == nil {
+		return false, fmt.Errorf("session setup before negotiate")
+	}
+
+	var resp []byte
+	if password != "" {
+		if c.negotiated.SecurityMode&SecurityChallengeResp == 0 {
+			return false, fmt.Errorf("server requires plaintext passwords")
+		}
+		if len(c.negotiated.Challenge) != 8 {
+			return false, fmt.Errorf("server sent no NTLM challenge (extended security only?)")
+		}
+		resp = ntlmv1Response(ntHash(password), c.negotiated.Challenge)
+	}
+
+	words := []byte{andxNone, 0, 0, 0}
+	words = binary.LittleEndian.AppendUint16(words, 0xffff) // max buffer
+	words = binary.LittleEndian.AppendUint16(words, 1)      // max mpx
+	words = binary.LittleEndian.AppendUint16(words, 1)      // VC number
+	words = binary.LittleEndian.AppendUint32(words, c.negotiated.SessionKey)
+	words = binary.LittleEndian.AppendUint16(words, uint16(len(resp)))
+	words = binary.LittleEndian.AppendUint16(words, uint16(len(resp)))
+	words = binary.LittleEndian.AppendUint32(words, 0)
+	words = binary.LittleEndian.AppendUint32(words, capUnicode|capLargeFiles|capNTSMBs|capNTStatus)
+
+	// The LM slot repeats the NT response, as clients do with LM disabled
+	data := append(append([]byte{}, resp...), resp...)
+	if (headerSize+3+len(words)+len(data))%2 != 0 {
+		data = append(data, 0)
+	}
+	for _, s := range []string{user, "", "Unix", "ps2smb"} {
+		data = append(data, encodeUTF16(s+"\x00")...)
+	}
+
+	r, err := c.call(cmdSessionSetup, words, data)
+	if err != nil {
+		return false, err
+	}
+	if len(r.words) < 6 {
+		return false, fmt.Errorf("malformed session setup response")
+	}
+
+	c.uid = r.uid
+	return binary.LittleEndian.Uint16(r.words[4:6])&0x0001 != 0, nil
+}
+
+// TreeConnect attaches to \\server\share
+func (c *Conn) TreeConnect(server, share string) error {
+	path := `\\` + strings.ToUpper(server) + `\` + strings.ToUpper(share)
+
+	words := []byte{andxNone, 0, 0, 0}
+	words = binary.LittleEndian.AppendUint16(words, 0) // flags
+	words = binary.LittleEndian.AppendUint16(words, 1) // password length
+
+	data := []byte{0}
+	data = append(data, encodeUTF16(path+"\x00")...)
+	data = append(data, "?????\x00"...)
+
+	r, err := c.call(cmdTreeConnect, words, data)
+	if err != nil {
+		return err
+	}
+	c.tid = r.tid
+	return nil
+}
+
+// Connect dials host on port 445, negotiates, logs in and attaches to share
+// in one step
+func Connect(host, share, user, password string, timeout time.Duration) (*Conn, error) {
+	c, err := Dial(host, 445, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.Negotiate(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if _, err := c.SessionSetup(user, password); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("session setup: %v", err)
+	}
+	if err := c.TreeConnect(host, share); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("tree connect: %v", err)
+	}
+	return c, nil
+}
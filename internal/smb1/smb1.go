@@ -0,0 +1,216 @@
+package smb1
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Dialect is the only SMB1 dialect OPL speaks
+const Dialect = "NT LM 0.12"
+
+// SMB1 commands
+const (
+	cmdClose        = 0x04
+	cmdReadAndX     = 0x2e
+	cmdTransaction2 = 0x32
+	cmdNegotiate    = 0x72
+	cmdSessionSetup = 0x73
+	cmdTreeConnect  = 0x75
+	cmdNTCreateAndX = 0xa2
+)
+
+// Header flags
+const (
+	flagsCaseless   = 0x08
+	flagsCanonical  = 0x10
+	flags2LongNames = 0x0001
+	flags2NTStatus  = 0x4000
+	flags2Unicode   = 0x8000
+	andxNone        = 0xff
+	headerSize      = 32
+	noDialect       = 0xffff
+)
+
+// Security mode bits from the negotiate response
+const (
+	SecurityUserLevel      = 0x01
+	SecurityChallengeResp  = 0x02
+	SecuritySignatures     = 0x04
+	SecuritySignaturesReqd = 0x08
+)
+
+// NegotiateResult is the server's answer to SMB_COM_NEGOTIATE
+type NegotiateResult struct {
+	SecurityMode  byte
+	MaxBufferSize uint32
+	SessionKey    uint32
+	Capabilities  uint32
+	Challenge     []byte
+}
+
+// Conn is an SMB1 connection to a server
+type Conn struct {
+	t   *transport
+	mid uint16
+	uid uint16
+	tid uint16
+
+	negotiated *NegotiateResult
+}
+
+// Dial connects to an SMB server on port 139 or 445
+func Dial(host string, port int, timeout time.Duration) (*Conn, error) {
+	t, err := dialTransport(host, port, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{t: t}, nil
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	return c.t.Close()
+}
+
+// Negotiate offers only the NT LM 0.12 dialect, exactly like OPL, and fails
+// if the server does not accept it
+func (c *Conn) Negotiate() (*NegotiateResult, error) {
+	data := append([]byte{0x02}, Dialect...)
+	data = append(data, 0)
+
+	resp, err := c.call(cmdNegotiate, nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	words := resp.words
+	if len(words) < 2 {
+		return nil, fmt.Errorf("malformed negotiate response")
+	}
+	if binary.LittleEndian.Uint16(words[0:2]) == noDialect {
+		return nil, fmt.Errorf("server rejected the %s dialect (SMB1 disabled?)", Dialect)
+	}
+	if len(words) < 34 {
+		return nil, fmt.Errorf("server did not answer with an NT LM 0.12 response")
+	}
+
+	result := &NegotiateResult{
+		SecurityMode:  words[2],
+		MaxBufferSize: binary.LittleEndian.Uint32(words[7:11]),
+		SessionKey:    binary.LittleEndian.Uint32(words[15:19]),
+		Capabilities:  binary.LittleEndian.Uint32(words[19:23]),
+	}
+	keyLen := int(words[33])
+	if keyLen > 0 && len(resp.data) >= keyLen {
+		result.Challenge = append([]byte{}, resp.data[:keyLen]...)
+	}
+
+	c.negotiated = result
+	return result, nil
+}
+
+// response is a decoded SMB1 message
+type response struct {
+	command byte
+	status  uint32
+	flags2  uint16
+	tid     uint16
+	uid     uint16
+	words   []byte
+	data    []byte
+	raw     []byte
+}
+
+// call sends a request with the given parameter words and data bytes
+func (c *Conn) call(command byte, words, data []byte) (*response, error) {
+	c.mid++
+
+	msg := make([]byte, headerSize, headerSize+3+len(words)+len(data))
+	copy(msg[0:4], "\xffSMB")
+	msg[4] = command
+	msg[9] = flagsCaseless | flagsCanonical
+	binary.LittleEndian.PutUint16(msg[10:12], flags2LongNames|flags2NTStatus|flags2Unicode)
+	binary.LittleEndian.PutUint16(msg[26:28], 0xfeff) // PID
+	binary.LittleEndian.PutUint16(msg[24:26], c.tid)
+	binary.LittleEndian.PutUint16(msg[28:30], c.uid)
+	binary.LittleEndian.PutUint16(msg[30:32], c.mid)
+
+	msg = append(msg, byte(len(words)/2))
+	msg = append(msg, words...)
+	msg = binary.LittleEndian.AppendUint16(msg, uint16(len(data)))
+	msg = append(msg, data...)
+
+	raw, err := c.t.roundTrip(msg)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := parseResponse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if resp.command != command {
+		return nil, fmt.Errorf("unexpected response command 0x%02x", resp.command)
+	}
+	if resp.status != 0 {
+		return resp, StatusError(resp.status)
+	}
+	return resp, nil
+}
+
+func parseResponse(raw []byte) (*response, error) {
+	if len(raw) < headerSize+3 || string(raw[0:4]) != "\xffSMB" {
+		if len(raw) >= 4 && string(raw[0:4]) == "\xfeSMB" {
+			return nil, fmt.Errorf("server answered with SMB2; SMB1 is disabled")
+		}
+		return nil, fmt.Errorf("not an SMB1 response")
+	}
+
+	resp := &response{
+		command: raw[4],
+		status:  binary.LittleEndian.Uint32(raw[5:9]),
+		flags2:  binary.LittleEndian.Uint16(raw[10:12]),
+		tid:     binary.LittleEndian.Uint16(raw[24:26]),
+		uid:     binary.LittleEndian.Uint16(raw[28:30]),
+		raw:     raw,
+	}
+
+	wc := int(raw[headerSize])
+	end := headerSize + 1 + wc*2
+	if len(raw) < end+2 {
+		return nil, fmt.Errorf("truncated SMB1 response")
+	}
+	resp.words = raw[headerSize+1 : end]
+	bc := int(binary.LittleEndian.Uint16(raw[end : end+2]))
+	if len(raw) < end+2+bc {
+		bc = len(raw) - end - 2
+	}
+	resp.data = raw[end+2 : end+2+bc]
+	return resp, nil
+}
+
+// StatusError is an NT status code returned by the server
+type StatusError uint32
+
+func (e StatusError) Error() string {
+	if name, ok := statusNames[uint32(e)]; ok {
+		return name
+	}
+	return fmt.Sprintf("NT status 0x%08x", uint32(e))
+}
+
+var statusNames = map[uint32]string{
+	0xc0000022: "access denied",
+	0xc000006d: "logon failure (wrong user or password)",
+	0xc000006e: "account restriction",
+	0xc0000072: "account disabled",
+	0xc00000cc: "share not found",
+	0xc0000034: "object not found",
+	0xc000000f: "no such file",
+	0xc00000ba: "file is a directory",
+	0xc000003a: "path not found",
+	0xc0000002: "not implemented",
+	0xc0000016: "more processing required",
+	0x80000006: "no more files",
+	0xc00000bb: "not supported",
+}
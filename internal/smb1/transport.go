@@ -0,0 +1,132 @@
+package smb1
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/matheusc457/ps2smb/internal/netbios"
+)
+
+// NetBIOS session service packet types (RFC 1002)
+const (
+	nbSessionMessage = 0x00
+	nbSessionRequest = 0x81
+	nbPositiveResp   = 0x82
+	nbNegativeResp   = 0x83
+	nbKeepAlive      = 0x85
+)
+
+// transport frames SMB messages with the 4-byte NetBIOS session header used
+// on both port 139 and port 445
+type transport struct {
+	conn    net.Conn
+	timeout time.Duration
+}
+
+// dialTransport connects to host:port; on port 139 a NetBIOS session is
+// established first using the wildcard *SMBSERVER name
+func dialTransport(host string, port int, timeout time.Duration) (*transport, error) {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &transport{conn: conn, timeout: timeout}
+	if port == 139 {
+		if err := t.sessionRequest("*SMBSERVER", "PS2SMB"); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (t *transport) Close() error {
+	return t.conn.Close()
+}
+
+func (t *transport) sessionRequest(called, calling string) error {
+	payload := append(netbios.EncodeName(called, 0x20), netbios.EncodeName(calling, 0x00)...)
+	if err := t.writeFrame(nbSessionRequest, payload); err != nil {
+		return err
+	}
+
+	typ, body, err := t.readFrame()
+	if err != nil {
+		return err
+	}
+	switch typ {
+	case nbPositiveResp:
+		return nil
+	case nbNegativeResp:
+		code := byte(0)
+		if len(body) > 0 {
+			code = body[0]
+		}
+		return fmt.Errorf("NetBIOS session rejected (error 0x%02x)", code)
+	}
+	return fmt.Errorf("unexpected NetBIOS session response 0x%02x", typ)
+}
+
+// roundTrip sends one SMB message and returns the next SMB message received
+func (t *transport) roundTrip(msg []byte) ([]byte, error) {
+	if err := t.writeFrame(nbSessionMessage, msg); err != nil {
+		return nil, err
+	}
+	return t.readMessage()
+}
+
+// readMessage returns the next SMB message, skipping keep-alives
+func (t *transport) readMessage() ([]byte, error) {
+	for {
+		typ, body, err := t.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		if typ == nbKeepAlive {
+			continue
+		}
+		if typ != nbSessionMessage {
+			return nil, fmt.Errorf("unexpected NetBIOS packet 0x%02x", typ)
+		}
+		return body, nil
+	}
+}
+
+func (t *transport) writeFrame(typ byte, payload []byte) error {
+	if len(payload) > 0xffffff {
+		return fmt.Errorf("message too large (%d bytes)", len(payload))
+	}
+	frame := make([]byte, 4+len(payload))
+	frame[0] = typ
+	frame[1] = byte(len(payload) >> 16)
+	binary.BigEndian.PutUint16(frame[2:4], uint16(len(payload)))
+	copy(frame[4:], payload)
+
+	t.conn.SetWriteDeadline(time.Now().Add(t.timeout))
+	_, err := t.conn.Write(frame)
+	return err
+}
+
+func (t *transport) readFrame() (byte, []byte, error) {
+	t.conn.SetReadDeadline(time.Now().Add(t.timeout))
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(t.conn, header); err != nil {
+		if err == io.EOF {
+			return 0, nil, fmt.Errorf("server closed the connection")
+		}
+		return 0, nil, err
+	}
+	length := int(header[1]&0x01)<<16 | int(binary.BigEndian.Uint16(header[2:4]))
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(t.conn, body); err != nil {
+		return 0, nil, err
+	}
+	return header[0], body, nil
+}
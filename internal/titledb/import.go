@@ -0,0 +1,296 @@
+package titledb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/matheusc457/ps2smb/internal/library"
+)
+
+// Formats ReadFile understands
+const (
+	FormatCSV   = "csv"
+	FormatXML   = "xml"
+	FormatPCSX2 = "pcsx2"
+)
+
+// Parsed is the content of an import file
+type Parsed struct {
+	Format  string
+	Entries map[string]library.TitleInfo
+	// Skipped counts records without a usable game ID or title
+	Skipped int
+}
+
+// ReadFile parses a title list. The format is chosen from the extension,
+// or from the content when the extension is not known:
+//
+//   - CSV/TSV with an optional header (id, title, region, languages);
+//     the delimiter may be a comma, semicolon, tab or pipe
+//   - XML: GameTDB ps2tdb.xml, or a Logiqx DAT with <serial> entries
+//   - PCSX2 GameIndex.yaml
+func ReadFile(path string) (*Parsed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Parsed{Format: detectFormat(path, data), Entries: make(map[string]library.TitleInfo)}
+	switch p.Format {
+	case FormatXML:
+		err = p.readXML(bytes.NewReader(data))
+	case FormatPCSX2:
+		err = p.readPCSX2(bytes.NewReader(data))
+	default:
+		err = p.readCSV(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if len(p.Entries) == 0 {
+		return nil, fmt.Errorf("%s contains no game IDs with titles", path)
+	}
+	return p, nil
+}
+
+func detectFormat(path string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml", ".dat":
+		return FormatXML
+	case ".yaml", ".yml":
+		return FormatPCSX2
+	case ".csv", ".tsv", ".txt":
+		return FormatCSV
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return FormatXML
+	}
+	return FormatCSV
+}
+
+// add records an entry, skipping ones without an ID or title
+func (p *Parsed) add(serial string, info library.TitleInfo) {
+	id, ok := library.NormalizeGameID(serial)
+	info.Title = strings.TrimSpace(info.Title)
+	if !ok || info.Title == "" {
+		p.Skipped++
+		return
+	}
+	info.Region = normalizeRegion(info.Region)
+	info.Languages = strings.TrimSpace(info.Languages)
+	p.Entries[id] = info
+}
+
+// csvColumns are the header names recognized for each field
+var csvColumns = map[string][]string{
+	"id":        {"id", "gameid", "game id", "serial", "code", "product code", "titleid"},
+	"title":     {"title", "name", "game", "game name", "gamename"},
+	"region":    {"region"},
+	"languages": {"languages", "language", "lang"},
+}
+
+func (p *Parsed) readCSV(data []byte) error {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = csvDelimiter(data)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	cols := map[string]int{"id": -1, "title": -1, "region": -1, "languages": -1}
+	header := false
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for field, names := range csvColumns {
+			for _, n := range names {
+				if name == n && cols[field] < 0 {
+					cols[field], header = i, true
+				}
+			}
+		}
+	}
+	if header {
+		records = records[1:]
+		if cols["id"] < 0 || cols["title"] < 0 {
+			return fmt.Errorf("header has no ID or title column")
+		}
+	} else {
+		// Without a header the ID column is the one holding a game ID and
+		// the title is the first other column
+		for i, v := range records[0] {
+			if _, ok := library.NormalizeGameID(v); ok {
+				cols["id"] = i
+				break
+			}
+		}
+		if cols["id"] < 0 {
+			return fmt.Errorf("no header and no game ID in the first line")
+		}
+		cols["title"] = 0
+		if cols["id"] == 0 {
+			cols["title"] = 1
+		}
+	}
+
+	field := func(rec []string, name string) string {
+		if i := cols[name]; i >= 0 && i < len(rec) {
+			return rec[i]
+		}
+		return ""
+	}
+	for _, rec := range records {
+		p.add(field(rec, "id"), library.TitleInfo{
+			Title:     field(rec, "title"),
+			Region:    field(rec, "region"),
+			Languages: field(rec, "languages"),
+		})
+	}
+	return nil
+}
+
+// csvDelimiter picks the most frequent candidate in the first line
+func csvDelimiter(data []byte) rune {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	best, count := ',', 0
+	for _, c := range []rune{',', ';', '\t', '|'} {
+		if n := bytes.Count(line, []byte(string(c))); n > count {
+			best, count = c, n
+		}
+	}
+	return best
+}
+
+// xmlGame covers both GameTDB (<id>, <locale><title>) and Logiqx DATs
+// (name attribute, <serial>)
+type xmlGame struct {
+	Name      string `xml:"name,attr"`
+	ID        string `xml:"id"`
+	Serial    string `xml:"serial"`
+	Region    string `xml:"region"`
+	Languages string `xml:"languages"`
+	Locales   []struct {
+		Lang  string `xml:"lang,attr"`
+		Title string `xml:"title"`
+	} `xml:"locale"`
+}
+
+func (p *Parsed) readXML(r io.Reader) error {
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "game" {
+			continue
+		}
+
+		var g xmlGame
+		if err := d.DecodeElement(&g, &start); err != nil {
+			return err
+		}
+
+		title := library.StripTitleTags(g.Name)
+		for i, l := range g.Locales {
+			if l.Title != "" && (i == 0 || strings.EqualFold(l.Lang, "EN")) {
+				title = l.Title
+			}
+		}
+		ids := g.ID
+		if ids == "" {
+			ids = g.Serial
+		}
+		for _, id := range strings.Split(ids, ",") {
+			p.add(id, library.TitleInfo{Title: title, Region: g.Region, Languages: g.Languages})
+		}
+	}
+}
+
+// readPCSX2 reads the flat subset of GameIndex.yaml it needs: top-level
+// serial keys with name, name-en and region values indented two spaces
+func (p *Parsed) readPCSX2(r io.Reader) error {
+	var serial string
+	var info library.TitleInfo
+	var nameEN string
+	flush := func() {
+		if serial != "" {
+			if nameEN != "" {
+				info.Title = nameEN
+			}
+			p.add(serial, info)
+		}
+		serial, info, nameEN = "", library.TitleInfo{}, ""
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		if line[0] != ' ' && line[0] != '\t' {
+			flush()
+			serial = key
+			continue
+		}
+		// Deeper levels hold patches and fixes, whose keys must not be read
+		if len(line)-len(strings.TrimLeft(line, " ")) != 2 {
+			continue
+		}
+		switch key {
+		case "name":
+			info.Title = value
+		case "name-en":
+			nameEN = value
+		case "region":
+			info.Region = value
+		}
+	}
+	flush()
+	return sc.Err()
+}
+
+// regionNames maps the region spellings of common databases to the
+// regions ps2smb derives from game ID prefixes
+var regionNames = map[string]library.Region{
+	"ntsc-u": library.RegionUSA, "usa": library.RegionUSA, "us": library.RegionUSA,
+	"pal": library.RegionEurope, "pal-e": library.RegionEurope, "europe": library.RegionEurope, "eur": library.RegionEurope,
+	"ntsc-j": library.RegionJapan, "japan": library.RegionJapan, "jpn": library.RegionJapan, "jp": library.RegionJapan,
+	"ntsc-k": library.RegionKorea, "korea": library.RegionKorea, "kor": library.RegionKorea,
+	"ntsc-c": library.RegionChina, "china": library.RegionChina, "chn": library.RegionChina,
+}
+
+func normalizeRegion(s string) string {
+	s = strings.TrimSpace(s)
+	if r, ok := regionNames[strings.ToLower(s)]; ok {
+		return string(r)
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package titledb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/matheusc457/ps2smb/internal/config"
+	"github.com/matheusc457/ps2smb/internal/library"
+)
+
+// FileName is the database file in the ps2smb config directory
+const FileName = "titles.json"
+
+// DB maps game IDs to titles. It is built offline from exports of other
+// tools (OPL Manager, GameTDB, DAT files) with 'ps2smb db import'
+type DB struct {
+	Sources []Source                     `json:"sources"`
+	Entries map[string]library.TitleInfo `json:"entries"`
+
+	path string
+}
+
+// Source records one imported file
+type Source struct {
+	File     string    `json:"file"`
+	Format   string    `json:"format"`
+	Entries  int       `json:"entries"`
+	Imported time.Time `json:"imported"`
+}
+
+// Path returns where the database is stored
+func Path() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Load reads the database; a missing file gives an empty database
+func Load() (*DB, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{Entries: make(map[string]library.TitleInfo), path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read title database: %v", err)
+	}
+	if err := json.Unmarshal(data, db); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if db.Entries == nil {
+		db.Entries = make(map[string]library.TitleInfo)
+	}
+	return db, nil
+}
+
+// Save writes the database, replacing the file atomically
+func (db *DB) Save() error {
+	data, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal title database: %v", err)
+	}
+	tmp := db.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write title database: %v", err)
+	}
+	if err := os.Rename(tmp, db.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write title database: %v", err)
+	}
+	return nil
+}
+
+// Lookup returns the entry for a game ID. It has the signature of
+// library.TitleLookup
+func (db *DB) Lookup(id string) (library.TitleInfo, bool) {
+	info, ok := db.Entries[id]
+	return info, ok
+}
+
+// Len returns the number of game IDs known
+func (db *DB) Len() int {
+	return len(db.Entries)
+}
+
+// MergeResult counts what an import changed
+type MergeResult struct {
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Merge adds imported entries, overwriting existing IDs. Region and
+// languages already known are kept when the new entry lacks them
+func (db *DB) Merge(src Source, entries map[string]library.TitleInfo) MergeResult {
+	var res MergeResult
+	for id, info := range entries {
+		old, ok := db.Entries[id]
+		if ok {
+			if info.Region == "" {
+				info.Region = old.Region
+			}
+			if info.Languages == "" {
+				info.Languages = old.Languages
+			}
+		}
+		switch {
+		case !ok:
+			res.Added++
+		case old == info:
+			res.Unchanged++
+		default:
+			res.Updated++
+		}
+		db.Entries[id] = info
+	}
+
+	src.Entries = len(entries)
+	db.Sources = append(db.Sources, src)
+	return res
+}
+
+// Reset drops every entry and source, for imports that replace the database
+func (db *DB) Reset() {
+	db.Sources = nil
+	db.Entries = make(map[string]library.TitleInfo)
+}
+
+// IDs returns the known game IDs in order
+func (db *DB) IDs() []string {
+	ids := make([]string, 0, len(db.Entries))
+	for id := range db.Entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}